@@ -2,10 +2,11 @@ module github.com/justjake/react4c
 
 go 1.18
 
+require golang.org/x/exp v0.0.0-20220325121720-054d8573a5d8
+
 require (
 	github.com/josharian/impl v1.1.0 // indirect
 	github.com/smartystreets/goconvey v1.7.2 // indirect
-	golang.org/x/exp v0.0.0-20220325121720-054d8573a5d8 // indirect
 	golang.org/x/mod v0.6.0-dev.0.20211013180041-c96bc1413d57 // indirect
 	golang.org/x/sys v0.0.0-20211019181941-9d821ace8654 // indirect
 	golang.org/x/tools v0.1.8-0.20211029000441-d6a9af8af023 // indirect
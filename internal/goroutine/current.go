@@ -0,0 +1,13 @@
+//go:build amd64 || arm64
+
+package goroutine
+
+// Current returns a token identifying the calling goroutine among the running
+// goroutines: the address of the runtime's goroutine struct. It's far cheaper
+// than ID, but a token may be reused by a new goroutine once its goroutine
+// exits, so it must not be kept past the goroutine's lifetime.
+func Current() uintptr {
+	return current()
+}
+
+func current() uintptr
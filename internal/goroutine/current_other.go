@@ -0,0 +1,9 @@
+//go:build !amd64 && !arm64
+
+package goroutine
+
+// Current returns a token identifying the calling goroutine among the running
+// goroutines. On this architecture, it's the goroutine's ID.
+func Current() uintptr {
+	return uintptr(ID())
+}
@@ -0,0 +1,27 @@
+// Package goroutine identifies the calling goroutine, for state that's scoped
+// to a render running on one goroutine.
+package goroutine
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+)
+
+var goroutinePrefix = []byte("goroutine ")
+
+// ID returns the calling goroutine's ID. Go doesn't expose goroutine IDs, but
+// the first line of a stack trace always reads "goroutine 123 [running]:".
+func ID() uint64 {
+	var buf [64]byte
+	stack := buf[:runtime.Stack(buf[:], false)]
+	stack = bytes.TrimPrefix(stack, goroutinePrefix)
+	if space := bytes.IndexByte(stack, ' '); space >= 0 {
+		stack = stack[:space]
+	}
+	id, err := strconv.ParseUint(string(stack), 10, 64)
+	if err != nil {
+		panic("goroutine: can't parse goroutine ID: " + err.Error())
+	}
+	return id
+}
@@ -0,0 +1,90 @@
+package react
+
+import "reflect"
+
+// Nodes is a list of children that can be passed anywhere a single child is
+// accepted. It renders like an unkeyed Fragment: the list takes a single slot
+// among its siblings, and keys inside it only need to be unique within the
+// list. So the length of a list never shifts the index-based keys of the
+// siblings after it, and they keep their state.
+type Nodes []AnyNode
+
+func (n Nodes) GetKey() *string {
+	return nil
+}
+
+func (n Nodes) ClearKey() bool {
+	return false
+}
+
+func (n Nodes) GetProps() any {
+	return FragmentProps{WithChildren: WithChildren{n}}
+}
+
+func (n Nodes) InvokeRender() AnyNode {
+	return Fragment(n...)
+}
+
+func (n Nodes) GetComponent() any {
+	return Fragment
+}
+
+func (n Nodes) GetChildren() []AnyNode {
+	return n
+}
+
+// Map renders each item in items. Give each returned node a key if items may
+// be re-ordered. Like any Nodes, the list takes one slot among its siblings.
+func Map[T any](items []T, render func(item T, index int) AnyNode) Nodes {
+	nodes := make(Nodes, len(items))
+	for i, item := range items {
+		nodes[i] = render(item, i)
+	}
+	return nodes
+}
+
+// If returns node when cond is true, and an empty placeholder otherwise. This
+// is Go's spelling of `cond && <node />`. The placeholder renders nothing, but
+// still occupies a slot so the index-based keys of its siblings don't shift.
+func If(cond bool, node AnyNode) AnyNode {
+	if cond {
+		return node
+	}
+	return nil
+}
+
+// IsEmpty reports if node renders nothing, which is the case for nil and for
+// nil pointers like (*Node[Props])(nil).
+func IsEmpty(node AnyNode) bool {
+	if node == nil {
+		return true
+	}
+	value := reflect.ValueOf(node)
+	return value.Kind() == reflect.Pointer && value.IsNil()
+}
+
+// FlattenChildren expands any Nodes lists nested in children, eg to count or
+// inspect them. The reconciler doesn't flatten lists; see Nodes. Empty children
+// are kept in place. Returns children as-is if there's nothing to flatten.
+func FlattenChildren(children []AnyNode) []AnyNode {
+	nested := false
+	for _, child := range children {
+		if _, ok := child.(Nodes); ok {
+			nested = true
+			break
+		}
+	}
+	if !nested {
+		return children
+	}
+
+	flat := make([]AnyNode, 0, len(children))
+	for _, child := range children {
+		if list, ok := child.(Nodes); ok {
+			flat = append(flat, FlattenChildren(list)...)
+		} else {
+			flat = append(flat, child)
+		}
+	}
+	return flat
+}
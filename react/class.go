@@ -0,0 +1,189 @@
+package react
+
+import (
+	"reflect"
+	"sync"
+)
+
+// ClassBase is embedded in the struct of a class component. It holds the
+// instance's props and state.
+//
+//	type Counter struct {
+//		ClassBase[CounterProps, int]
+//	}
+//
+//	func (c *Counter) Render() AnyNode {
+//		return Text.F("%d", c.State())
+//	}
+type ClassBase[Props IProps, State any] struct {
+	mu        sync.Mutex
+	props     Props
+	state     State
+	updates   []func(State) State
+	callbacks HookCallbacks
+}
+
+// Props of the current render.
+func (b *ClassBase[Props, State]) Props() Props {
+	return b.props
+}
+
+// State of the current render.
+func (b *ClassBase[Props, State]) State() State {
+	return b.state
+}
+
+// SetState replaces the state and re-renders. Safe to call from any
+// goroutine. Calling it in the constructor sets the initial state.
+func (b *ClassBase[Props, State]) SetState(next State) {
+	b.UpdateState(func(State) State { return next })
+}
+
+// UpdateState computes the next state from the latest state, and re-renders.
+// Updates are applied in order at the next render.
+func (b *ClassBase[Props, State]) UpdateState(update func(prev State) State) {
+	b.mu.Lock()
+	b.updates = append(b.updates, update)
+	callbacks := b.callbacks
+	b.mu.Unlock()
+	if callbacks != nil {
+		callbacks.ShouldRerender()
+	}
+}
+
+func (b *ClassBase[Props, State]) beginRender(props Props, callbacks HookCallbacks) {
+	b.mu.Lock()
+	updates := b.updates
+	b.updates = nil
+	b.callbacks = callbacks
+	b.mu.Unlock()
+	b.props = props
+	for _, update := range updates {
+		b.state = update(b.state)
+	}
+}
+
+// ClassInstance is implemented by pointers to structs that embed ClassBase and
+// have a Render method.
+type ClassInstance[Props IProps] interface {
+	Render() AnyNode
+	beginRender(props Props, callbacks HookCallbacks)
+}
+
+// Optional lifecycle methods of class instances.
+type ComponentDidMounter interface {
+	// Called after the instance's first render is committed.
+	ComponentDidMount()
+}
+
+type ComponentDidUpdater[Props IProps] interface {
+	// Called after every later render is committed.
+	ComponentDidUpdate(prevProps Props)
+}
+
+type ComponentWillUnmounter interface {
+	// Called before the instance is removed.
+	ComponentWillUnmount()
+}
+
+type ShouldComponentUpdater[Props IProps] interface {
+	// Called when the parent re-renders. Returning false skips re-rendering
+	// with nextProps. State updates always re-render.
+	ShouldComponentUpdate(nextProps Props) bool
+}
+
+// Internal interface between class components and the reconciler, which keeps
+// an instance per fiber.
+type ClassComponent interface {
+	NewInstance(props any) any
+	ShouldUpdate(instance any, nextProps any) bool
+}
+
+// ClassComponentType is a component rendered by a struct instance, see Class.
+type ClassComponentType[Props IProps, T any, PT interface {
+	*T
+	ClassInstance[Props]
+}] struct {
+	newInstance func(props Props) PT
+}
+
+// Class creates a component from a constructor of class instances. Each
+// mounted component gets its own instance, created with its first props.
+// Refs to the component point at the instance.
+func Class[Props IProps, T any, PT interface {
+	*T
+	ClassInstance[Props]
+}](newInstance func(props Props) PT) *ClassComponentType[Props, T, PT] {
+	return &ClassComponentType[Props, T, PT]{newInstance: newInstance}
+}
+
+func (c *ClassComponentType[Props, T, PT]) Render(props Props) AnyNode {
+	host := currentHookHost()
+	instance := host.PublicInstance().(PT)
+	hook, _ := getOrCreateHook(host, func() *classHook[Props] {
+		return &classHook[Props]{instance: instance}
+	})
+	instance.beginRender(props, host.HookCallbacks())
+	hook.props = props
+	hook.rendered = true
+	return instance.Render()
+}
+
+func (c *ClassComponentType[Props, T, PT]) Node(props Props, children ...AnyNode) AnyNode {
+	return JSX[Props](c, props, children...)
+}
+
+func (c *ClassComponentType[Props, T, PT]) DisplayName() string {
+	return reflect.TypeOf((*T)(nil)).Elem().Name()
+}
+
+func (c *ClassComponentType[Props, T, PT]) NewInstance(props any) any {
+	return c.newInstance(props.(Props))
+}
+
+func (c *ClassComponentType[Props, T, PT]) ShouldUpdate(instance any, nextProps any) bool {
+	if should, ok := instance.(ShouldComponentUpdater[Props]); ok {
+		return should.ShouldComponentUpdate(nextProps.(Props))
+	}
+	return true
+}
+
+// Calls the instance's lifecycle methods.
+type classHook[Props IProps] struct {
+	instance       ClassInstance[Props]
+	props          Props // of the latest render
+	committedProps Props
+	mounted        bool
+	rendered       bool // since the last commit
+}
+
+func (hook *classHook[Props]) Unmount() {
+	if unmounter, ok := hook.instance.(ComponentWillUnmounter); ok && hook.mounted {
+		unmounter.ComponentWillUnmount()
+	}
+}
+
+// Like React, lifecycle methods run with layout effects, before the host
+// paints.
+func (*classHook[Props]) EffectPhase() EffectPhase {
+	return LayoutEffect
+}
+
+func (*classHook[Props]) CleanupEffect() {}
+
+func (hook *classHook[Props]) RunEffect() {
+	if !hook.rendered {
+		return
+	}
+	hook.rendered = false
+	prevProps := hook.committedProps
+	hook.committedProps = hook.props
+	if !hook.mounted {
+		hook.mounted = true
+		if mounter, ok := hook.instance.(ComponentDidMounter); ok {
+			mounter.ComponentDidMount()
+		}
+	} else if updater, ok := hook.instance.(ComponentDidUpdater[Props]); ok {
+		updater.ComponentDidUpdate(prevProps)
+	}
+}
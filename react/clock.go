@@ -0,0 +1,144 @@
+package react
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells time for timer hooks. Roots use the real clock unless given
+// another, eg a FakeClock in tests.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer sends the time on C once its duration has passed, unless stopped.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// RealClock is a Clock using package time.
+type RealClock struct{}
+
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
+
+func (RealClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+type realTimer struct {
+	timer *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t realTimer) Stop() bool {
+	return t.timer.Stop()
+}
+
+// FakeClock is a Clock whose time only moves when Advance is called.
+type FakeClock struct {
+	mu      sync.Mutex
+	changed *sync.Cond // Broadcast when timers are added or removed
+	now     time.Time
+	timers  []*fakeTimer
+}
+
+type fakeTimer struct {
+	clock *FakeClock
+	at    time.Time
+	c     chan time.Time
+}
+
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.changed = sync.NewCond(&c.mu)
+	return c
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	timer := &fakeTimer{clock: c, at: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		timer.c <- c.now
+	} else {
+		c.timers = append(c.timers, timer)
+		c.changed.Broadcast()
+	}
+	return timer
+}
+
+// Advance moves time forward by d, firing the timers that come due in order.
+// Timers fire on their channels, so hooks waiting on them run shortly after
+// Advance returns, on their own goroutines.
+//
+// Each call fires only the timers waiting when it's called, so a 1s UseInterval
+// ticks once for Advance(3 * time.Second), not three times: the interval sets
+// its next timer after Advance returns. To tick it three times, Advance by 1s
+// three times, calling BlockUntil(1) before each to wait for the timer.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	sort.SliceStable(c.timers, func(i, j int) bool {
+		return c.timers[i].at.Before(c.timers[j].at)
+	})
+	pending := c.timers[:0]
+	for _, timer := range c.timers {
+		if timer.at.After(c.now) {
+			pending = append(pending, timer)
+		} else {
+			timer.c <- timer.at
+		}
+	}
+	c.timers = pending
+	c.changed.Broadcast()
+}
+
+// BlockUntil waits until n timers are waiting, eg for a hook's goroutine to
+// set its timer before calling Advance.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.timers) != n {
+		c.changed.Wait()
+	}
+}
+
+// Waiting reports how many timers haven't fired or been stopped.
+func (c *FakeClock) Waiting() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, timer := range c.timers {
+		if timer == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			c.changed.Broadcast()
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package react
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClockAdvance(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := NewFakeClock(start)
+	late := clock.NewTimer(2 * time.Second)
+	early := clock.NewTimer(time.Second)
+	stopped := clock.NewTimer(time.Second)
+	if !stopped.Stop() {
+		t.Error("Stop() = false for a waiting timer, want true")
+	}
+	if got := clock.Waiting(); got != 2 {
+		t.Fatalf("Waiting() = %d, want 2", got)
+	}
+
+	clock.Advance(1500 * time.Millisecond)
+	if got := clock.Now(); !got.Equal(start.Add(1500 * time.Millisecond)) {
+		t.Errorf("Now() = %v after advancing 1.5s", got)
+	}
+	select {
+	case at := <-early.C():
+		if !at.Equal(start.Add(time.Second)) {
+			t.Errorf("1s timer fired with %v, want its due time", at)
+		}
+	default:
+		t.Error("1s timer didn't fire after 1.5s")
+	}
+	select {
+	case <-late.C():
+		t.Error("2s timer fired after 1.5s")
+	default:
+	}
+	select {
+	case <-stopped.C():
+		t.Error("stopped timer fired")
+	default:
+	}
+
+	clock.Advance(time.Second)
+	<-late.C()
+	if late.Stop() {
+		t.Error("Stop() = true for a fired timer, want false")
+	}
+	if got := clock.Waiting(); got != 0 {
+		t.Errorf("Waiting() = %d after all timers fired, want 0", got)
+	}
+
+	select {
+	case <-clock.NewTimer(0).C():
+	default:
+		t.Error("timer for 0s didn't fire right away")
+	}
+}
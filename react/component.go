@@ -22,16 +22,41 @@ func (c ComponentFunc[Props]) Node(props Props, children ...AnyNode) AnyNode {
 	return JSX[Props](c, props, children...)
 }
 
+// FuncComponent wraps a ComponentFunc with a stable identity. Funcs can't be
+// compared in Go, so the reconciler uses the wrapper's pointer to decide if a
+// component changed between renders.
+type FuncComponent[Props IProps] struct {
+	render ComponentFunc[Props]
+	name   string
+}
+
+func (c *FuncComponent[Props]) Render(props Props) AnyNode {
+	return c.render(props)
+}
+
+func (c *FuncComponent[Props]) Node(props Props, children ...AnyNode) AnyNode {
+	return JSX[Props](c, props, children...)
+}
+
+func (c *FuncComponent[Props]) DisplayName() string {
+	return c.name
+}
+
 // FunctionComponent infers the ComponentFunc from a function that takes a
-// IProps and returns an AnyNode.
-func FunctionComponent[Props IProps](fn ComponentFunc[Props]) ComponentFunc[Props] {
-	return ComponentFunc[Props](fn)
+// IProps and returns an AnyNode. Its display name is the name of fn.
+func FunctionComponent[Props IProps](fn ComponentFunc[Props]) *FuncComponent[Props] {
+	return &FuncComponent[Props]{render: fn, name: DisplayName(fn)}
+}
+
+// NamedFunctionComponent is FunctionComponent with an explicit display name.
+func NamedFunctionComponent[Props IProps](name string, fn ComponentFunc[Props]) *FuncComponent[Props] {
+	return &FuncComponent[Props]{render: fn, name: name}
 }
 
 // Text component renders its contents as Text
-var Text textComponent
+var Text TextComponent
 
-type textComponent func(string) *Node[TextProps]
+type TextComponent func(string) *Node[TextProps]
 
 func init() {
 	Text = textComponentImpl
@@ -49,18 +74,22 @@ type TextProps struct {
 	WithKey
 }
 
-func (t textComponent) Render(props TextProps) AnyNode {
+func (t TextComponent) Render(props TextProps) AnyNode {
 	return t(props.Text)
 }
 
-func (t textComponent) F(format string, a ...any) AnyNode {
+func (t TextComponent) F(format string, a ...any) AnyNode {
 	return t(fmt.Sprintf(format, a...))
 }
 
+func (TextComponent) DisplayName() string {
+	return "Text"
+}
+
 // Fragment only renders its children.
-var Fragment fragmentComponent
+var Fragment FragmentComponent
 
-type fragmentComponent func(...AnyNode) *Node[FragmentProps]
+type FragmentComponent func(...AnyNode) *Node[FragmentProps]
 
 func fragmentComponentImpl(children ...AnyNode) *Node[FragmentProps] {
 	return JSX[FragmentProps](Fragment, FragmentProps{}, children...)
@@ -71,11 +100,21 @@ type FragmentProps struct {
 	WithKey
 }
 
-func (f fragmentComponent) Render(props FragmentProps) AnyNode {
+func (f FragmentComponent) Render(props FragmentProps) AnyNode {
 	return f.Node(props)
 }
 
-func (f fragmentComponent) Node(props FragmentProps, children ...AnyNode) AnyNode {
+// Keyed creates a Fragment with the given key, eg for returning several
+// nodes per item from Map.
+func (f FragmentComponent) Keyed(key string, children ...AnyNode) *Node[FragmentProps] {
+	return JSX[FragmentProps](f, FragmentProps{WithKey: Key(key)}, children...)
+}
+
+func (FragmentComponent) DisplayName() string {
+	return "Fragment"
+}
+
+func (f FragmentComponent) Node(props FragmentProps, children ...AnyNode) AnyNode {
 	return JSX[FragmentProps](f, props, children...)
 }
 
@@ -131,3 +170,58 @@ func (*ComparableMemoComponent[Props, Comp]) PropsEqual(prev any, next any) bool
 	}
 	return comparablePrev == comparableNext
 }
+
+func (m *ComparableMemoComponent[Props, Comp]) DisplayName() string {
+	return "Memo(" + DisplayName(*m.comp) + ")"
+}
+
+// MemoFuncComponent will only re-render if its props change according to its
+// equality function. Unlike ComparableMemoComponent, it works with any
+// component and props, including function components and props that embed
+// WithChildren.
+type MemoFuncComponent[Props IProps] struct {
+	comp  Component[Props]
+	equal func(prev Props, next Props) bool
+}
+
+// MemoFunc memoizes comp, re-rendering only when equal(prev, next) is false.
+// If equal is nil, props are compared with ShallowEqual.
+func MemoFunc[Props IProps](comp Component[Props], equal func(prev Props, next Props) bool) *MemoFuncComponent[Props] {
+	if equal == nil {
+		equal = func(prev Props, next Props) bool {
+			return ShallowEqual(prev, next)
+		}
+	}
+	return &MemoFuncComponent[Props]{comp, equal}
+}
+
+func (m *MemoFuncComponent[Props]) Render(props Props) AnyNode {
+	return m.comp.Render(props)
+}
+
+func (m *MemoFuncComponent[Props]) Node(props Props, children ...AnyNode) AnyNode {
+	return JSX[Props](m, props, children...)
+}
+
+func (m *MemoFuncComponent[Props]) SameComponent(other any) bool {
+	if otherMemo, ok := other.(*MemoFuncComponent[Props]); ok {
+		return m == otherMemo
+	}
+	return false
+}
+
+func (m *MemoFuncComponent[Props]) PropsEqual(prev any, next any) bool {
+	typedPrev, prevOk := prev.(Props)
+	if !prevOk {
+		return false
+	}
+	typedNext, nextOk := next.(Props)
+	if !nextOk {
+		return false
+	}
+	return m.equal(typedPrev, typedNext)
+}
+
+func (m *MemoFuncComponent[Props]) DisplayName() string {
+	return "Memo(" + DisplayName(m.comp) + ")"
+}
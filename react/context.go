@@ -0,0 +1,109 @@
+package react
+
+// Context passes a value to every component below its Provider, without
+// threading it through props.
+type Context[T any] struct {
+	defaultValue T
+	Provider     *ContextProvider[T]
+	Consumer     *ContextConsumer[T]
+}
+
+// CreateContext creates a Context. Components with no Provider above them
+// read defaultValue.
+func CreateContext[T any](defaultValue T) *Context[T] {
+	ctx := &Context[T]{defaultValue: defaultValue}
+	ctx.Provider = &ContextProvider[T]{context: ctx}
+	ctx.Consumer = &ContextConsumer[T]{context: ctx}
+	return ctx
+}
+
+// Provide returns a Provider node that gives value to children.
+func (ctx *Context[T]) Provide(value T, children ...AnyNode) AnyNode {
+	return ctx.Provider.Node(ProviderProps[T]{Value: value}, children...)
+}
+
+type ProviderProps[T any] struct {
+	WithKey
+	WithChildren
+	Value T
+}
+
+// ContextProvider renders its children, and provides Value to the components
+// among them that call UseContext.
+type ContextProvider[T any] struct {
+	context *Context[T]
+}
+
+func (p *ContextProvider[T]) Render(props ProviderProps[T]) AnyNode {
+	return Fragment(props.Children...)
+}
+
+func (p *ContextProvider[T]) Node(props ProviderProps[T], children ...AnyNode) AnyNode {
+	return JSX[ProviderProps[T]](p, props, children...)
+}
+
+func (p *ContextProvider[T]) DisplayName() string {
+	return "Context.Provider"
+}
+
+func (p *ContextProvider[T]) ProvidedContext() any {
+	return p.context
+}
+
+func (p *ContextProvider[T]) ProvidedValue(props any) any {
+	return props.(ProviderProps[T]).Value
+}
+
+func (p *ContextProvider[T]) ValueChanged(prevProps any, nextProps any) bool {
+	return !depValueEqual(p.ProvidedValue(prevProps), p.ProvidedValue(nextProps))
+}
+
+// Consume returns a Consumer node that renders the context's value with
+// render.
+func (ctx *Context[T]) Consume(render func(T) AnyNode) AnyNode {
+	return ctx.Consumer.Node(ConsumerProps[T]{ChildrenFunc: ChildrenFunc[T]{render}})
+}
+
+type ConsumerProps[T any] struct {
+	WithKey
+	ChildrenFunc[T]
+}
+
+// ContextConsumer calls its function child with the context's value, like
+// UseContext, for rendering the value without writing a component.
+type ContextConsumer[T any] struct {
+	context *Context[T]
+}
+
+func (c *ContextConsumer[T]) Render(props ConsumerProps[T]) AnyNode {
+	return props.RenderChildren(UseContext(c.context))
+}
+
+func (c *ContextConsumer[T]) Node(props ConsumerProps[T], children ...AnyNode) AnyNode {
+	return JSX[ConsumerProps[T]](c, props, children...)
+}
+
+func (c *ContextConsumer[T]) DisplayName() string {
+	return "Context.Consumer"
+}
+
+// Internal interface between context providers and the reconciler.
+type ContextProviderComponent interface {
+	// The *Context[T] provided.
+	ProvidedContext() any
+	// The value provided by a node with props.
+	ProvidedValue(props any) any
+	// Reports if the provided value changed, compared like dependencies.
+	ValueChanged(prevProps any, nextProps any) bool
+}
+
+// UseContext returns the value of the nearest Provider of ctx above the
+// calling component, or ctx's default value if there is none. The component
+// re-renders when the provided value changes, even if a memoized component
+// between them bails out.
+func UseContext[T any](ctx *Context[T]) T {
+	if value, ok := currentHookHost().ReadContext(ctx); ok {
+		return value.(T)
+	}
+	return ctx.defaultValue
+}
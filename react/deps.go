@@ -0,0 +1,89 @@
+package react
+
+import "reflect"
+
+// Hooks that take dependencies (UseMemo, UseCallback, UseEffect and friends)
+// compute or run on mount, and then again whenever the dependencies change
+// compared to the previous render. Dependencies are usually a single value,
+// or a struct of values, compared with ==. Two sentinels make the other common
+// cases explicit:
+//
+//	UseEffect(fn, Always{}) // every render
+//	UseEffect(fn, Once{})   // on mount only
+//
+// Dependencies must be comparable, so use Deps for values that aren't, like
+// slices, maps and funcs:
+//
+//	UseMemo(fn, Deps(items, onChange))
+
+// Always is a dependency that changes every render.
+type Always struct{}
+
+// Once is a dependency that never changes, so the hook only computes or runs on
+// mount.
+type Once struct{}
+
+// DepsEqual lets a dependency decide if it's equal to the previous render's
+// dependency, instead of comparing with ==. Implementations are usually
+// pointers, since they need to be comparable to be used as dependencies.
+type DepsEqual interface {
+	DepsEqual(prev any) bool
+}
+
+// DepList is a list of dependencies of any type. See Deps.
+type DepList struct {
+	values []any
+}
+
+// Deps lists dependencies of any type. Values are compared element by element
+// with the previous render's list: reference types (pointers, slices, maps,
+// funcs and channels) by identity, and everything else by value.
+//
+// Slices are the same if they share a backing array and length, so appending
+// to a slice changes it, but mutating an element in place doesn't. Funcs are
+// the same if they're the same closure.
+func Deps(values ...any) *DepList {
+	return &DepList{values}
+}
+
+func (d *DepList) DepsEqual(prev any) bool {
+	prevList, ok := prev.(*DepList)
+	if !ok || prevList == nil || len(prevList.values) != len(d.values) {
+		return false
+	}
+	for i, value := range d.values {
+		if !depValueEqual(prevList.values[i], value) {
+			return false
+		}
+	}
+	return true
+}
+
+func depValueEqual(a any, b any) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if !va.IsValid() || !vb.IsValid() {
+		return va.IsValid() == vb.IsValid()
+	}
+	if va.Type() != vb.Type() {
+		return false
+	}
+	switch va.Kind() {
+	case reflect.Slice:
+		return va.Pointer() == vb.Pointer() && va.Len() == vb.Len()
+	}
+	if mayHoldFuncs(va.Type()) {
+		va, vb = addressable(va), addressable(vb)
+	}
+	return identical(va, vb)
+}
+
+// Report if dependencies changed since the previous render.
+func depsChanged[Deps comparable](prev Deps, next Deps) bool {
+	if _, always := any(next).(Always); always {
+		return true
+	}
+	if equaler, ok := any(next).(DepsEqual); ok {
+		return !equaler.DepsEqual(prev)
+	}
+	return prev != next
+}
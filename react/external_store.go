@@ -0,0 +1,97 @@
+package react
+
+import "sync"
+
+// Internal interface between UseSyncExternalStore and the reconciler. Before
+// committing a render, the reconciler asks each store hook rendered in it if
+// its store changed since the render read it, and renders those components
+// again, so the committed tree never mixes old and new snapshots.
+type ExternalStoreHook interface {
+	HookInstance
+	SnapshotChanged() bool
+}
+
+type externalStoreHook[T any] struct {
+	mu          sync.Mutex // onChange may be called from any goroutine
+	getSnapshot func() T
+	snapshot    T // read by the latest render
+	unmounted   bool
+
+	callbacks       HookCallbacks
+	subscribe       func(onChange func()) func() // from the latest render
+	subscribed      func(onChange func()) func() // of the current subscription
+	unsubscribe     func()
+	hasSubscription bool
+}
+
+func (store *externalStoreHook[T]) SnapshotChanged() bool {
+	store.mu.Lock()
+	getSnapshot, snapshot := store.getSnapshot, store.snapshot
+	store.mu.Unlock()
+	return !depValueEqual(getSnapshot(), snapshot)
+}
+
+func (store *externalStoreHook[T]) onChange() {
+	store.mu.Lock()
+	unmounted := store.unmounted
+	store.mu.Unlock()
+	if !unmounted && store.SnapshotChanged() {
+		store.callbacks.ShouldRerender()
+	}
+}
+
+func (store *externalStoreHook[T]) Unmount() {
+	store.mu.Lock()
+	store.unmounted = true
+	store.mu.Unlock()
+	if store.hasSubscription {
+		store.unsubscribe()
+		store.hasSubscription = false
+	}
+}
+
+// Subscribe during layout effects, so the store can't change unnoticed
+// between committing and passive effects.
+func (*externalStoreHook[T]) EffectPhase() EffectPhase {
+	return LayoutEffect
+}
+
+func (*externalStoreHook[T]) CleanupEffect() {}
+
+func (store *externalStoreHook[T]) RunEffect() {
+	if !store.hasSubscription || !depValueEqual(store.subscribe, store.subscribed) {
+		if store.hasSubscription {
+			store.unsubscribe()
+		}
+		store.subscribed = store.subscribe
+		store.unsubscribe = store.subscribe(store.onChange)
+		store.hasSubscription = true
+	}
+	// The store may have changed after rendering, before we subscribed.
+	store.onChange()
+}
+
+// UseSyncExternalStore reads a snapshot of an external data source, and
+// re-renders the component whenever the snapshot changes.
+//
+// subscribe registers onChange to be called, from any goroutine, whenever the
+// store changes, and returns a func that unregisters it. The component
+// subscribes when it commits, and resubscribes if subscribe changes identity,
+// so pass a func that's stable across renders, eg from UseCallback.
+//
+// getSnapshot returns the store's current value. It must return an identical
+// value until the store changes: snapshots are compared like dependencies,
+// so slices and maps are compared by identity rather than by contents.
+func UseSyncExternalStore[T any](subscribe func(onChange func()) (unsubscribe func()), getSnapshot func() T) T {
+	host := currentHookHost()
+	hook, _ := getOrCreateHook(host, func() *externalStoreHook[T] {
+		return &externalStoreHook[T]{callbacks: host.HookCallbacks()}
+	})
+	snapshot := getSnapshot()
+	hook.mu.Lock()
+	hook.getSnapshot = getSnapshot
+	hook.snapshot = snapshot
+	hook.mu.Unlock()
+	hook.subscribe = subscribe
+	return snapshot
+}
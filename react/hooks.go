@@ -1,5 +1,16 @@
 package react
 
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/justjake/react4c/internal/goroutine"
+)
+
 // Internal interface between a hook instance and internal reconciler machinery
 // for handling state updates.
 type HookCallbacks interface {
@@ -9,13 +20,67 @@ type HookCallbacks interface {
 type HookHost interface {
 	HookCallbacks() HookCallbacks
 	GetOrCreateHook(makeHook func() HookInstance) (instance HookInstance, found bool)
+	// Identifier for the hook being created, derived from the component's
+	// position in the tree. Rendering the same tree gives the same identifiers,
+	// on the server or the client. Only valid inside makeHook.
+	Identifier() string
+	// Go runs fn in a new goroutine owned by the component's root. Unmounting
+	// the root waits for fn to return.
+	Go(fn func())
+	// ReadContext returns the value of the nearest provider of context above
+	// the component, and subscribes the component to changes to it.
+	ReadContext(context any) (value any, ok bool)
+	// Clock of the component's root.
+	Clock() Clock
+	// Storage of the component's root, for UsePersistentState.
+	Storage() StateStorage
+	// Instance of a class component, created by the reconciler.
+	PublicInstance() any
 }
 
 type HookInstance interface {
 	Unmount()
 }
 
-var currentHookHost HookHost
+// Hook hosts are tracked per goroutine, so independent roots may render in
+// parallel. Rendering a component is always synchronous, so the goroutine
+// running the render is the one calling hooks.
+var hookHosts sync.Map // goroutine.Current() -> HookHost
+
+// RenderWithHooks calls render with host as the current hook host for the
+// calling goroutine. Renders may nest; the previous host is restored when
+// render returns.
+func RenderWithHooks(host HookHost, render func() AnyNode) AnyNode {
+	id := goroutine.Current()
+	prev, hadPrev := hookHosts.Load(id)
+	hookHosts.Store(id, host)
+	defer func() {
+		if hadPrev {
+			hookHosts.Store(id, prev)
+		} else {
+			hookHosts.Delete(id)
+		}
+	}()
+	return render()
+}
+
+// RenderingHookHost returns the hook host of the component rendering on the
+// calling goroutine, or nil if the goroutine isn't rendering.
+func RenderingHookHost() HookHost {
+	if host, ok := hookHosts.Load(goroutine.Current()); ok {
+		return host.(HookHost)
+	}
+	return nil
+}
+
+// Returns the hook host of the component rendering on this goroutine.
+// Panics if called outside of a render.
+func currentHookHost() HookHost {
+	if host := RenderingHookHost(); host != nil {
+		return host
+	}
+	panic("react: hooks can only be called while rendering a component")
+}
 
 func getOrCreateHook[HookType HookInstance](hookHost HookHost, makeHook func() HookType) (instance HookType, found bool) {
 	untyped, found := hookHost.GetOrCreateHook(func() HookInstance {
@@ -32,7 +97,8 @@ func (r *refHook[T]) Unmount() {}
 
 // Create a ref in the current component.
 func UseRef[T any]() *RefStruct[*T] {
-	hook, _ := getOrCreateHook(currentHookHost, func() *refHook[*T] {
+	host := currentHookHost()
+	hook, _ := getOrCreateHook(host, func() *refHook[*T] {
 		return &refHook[*T]{}
 	})
 	return &hook.ref
@@ -40,7 +106,8 @@ func UseRef[T any]() *RefStruct[*T] {
 
 // Create a ref with the given initial value.
 func UseRefInitial[T any](initialValue T) *RefStruct[T] {
-	hook, _ := getOrCreateHook(currentHookHost, func() *refHook[T] {
+	host := currentHookHost()
+	hook, _ := getOrCreateHook(host, func() *refHook[T] {
 		return &refHook[T]{
 			ref: RefStruct[T]{
 				Current: initialValue,
@@ -50,66 +117,180 @@ func UseRefInitial[T any](initialValue T) *RefStruct[T] {
 	return &hook.ref
 }
 
+// Renders may be thrown away and run again before they're committed, eg after
+// a render-phase update, so hooks compare dependencies with the committed
+// render's, and keep values from the latest render until it commits.
 type memoHook[T any, Dep comparable] struct {
-	prev     T
-	prevDeps Dep
+	value     T // of the committed render
+	deps      Dep
+	committed bool
+	pending   *memoValue[T, Dep] // computed by the latest render
+}
+
+type memoValue[T any, Dep comparable] struct {
+	value T
+	deps  Dep
 }
 
 func (*memoHook[T, Dep]) Unmount() {}
 
+// Commit during the insertion phase, before any effect of the commit runs.
+func (*memoHook[T, Dep]) EffectPhase() EffectPhase {
+	return InsertionEffect
+}
+
+func (*memoHook[T, Dep]) CleanupEffect() {}
+
+func (memo *memoHook[T, Dep]) RunEffect() {
+	if memo.pending != nil {
+		memo.value, memo.deps = memo.pending.value, memo.pending.deps
+		memo.committed = true
+		memo.pending = nil
+	}
+}
+
+// UseMemo returns the result of compute, re-computing it only when
+// dependencies change. See Always and Once.
 func UseMemo[T any, Dep comparable](compute func() T, dependencies Dep) T {
-	hook, found := getOrCreateHook(currentHookHost, func() *memoHook[T, Dep] {
-		return &memoHook[T, Dep]{
-			prev:     compute(),
-			prevDeps: dependencies,
-		}
+	host := currentHookHost()
+	hook, _ := getOrCreateHook(host, func() *memoHook[T, Dep] {
+		return &memoHook[T, Dep]{}
 	})
-	if found && dependencies != hook.prevDeps {
-		hook.prev = compute()
+	if hook.committed && !depsChanged(hook.deps, dependencies) {
+		hook.pending = nil
+		return hook.value
 	}
-	return hook.prev
+	value := compute()
+	hook.pending = &memoValue[T, Dep]{value, dependencies}
+	return value
 }
 
-type stateHook[T comparable] struct {
-	current T
-	handle  HookCallbacks
+type reducerHook[S any, A any] struct {
+	mu       sync.Mutex // dispatch may be called from any goroutine
+	current  S
+	reducer  func(S, A) S // from the latest render
+	equal    func(S, S) bool
+	queue    []A
+	handle   HookCallbacks
+	dispatch func(A)
 }
 
-func (state *stateHook[T]) Unmount() {
-	state.handle = nil
+func (r *reducerHook[S, A]) Unmount() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handle = nil
+	r.queue = nil
 }
 
-func (state *stateHook[T]) SetState(nextState T) {
-	if state.handle == nil {
+func (r *reducerHook[S, A]) Dispatch(action A) {
+	r.mu.Lock()
+	handle := r.handle
+	if handle == nil {
+		r.mu.Unlock()
 		return
 	}
-
-	if state.current == nextState {
+	if len(r.queue) == 0 && r.equal != nil && r.equal(r.reducer(r.current, action), r.current) {
+		// Eager bailout: the action doesn't change anything, so there's no
+		// need to re-render.
+		r.mu.Unlock()
 		return
 	}
+	r.queue = append(r.queue, action)
+	r.mu.Unlock()
 
-	state.current = nextState
-	state.handle.ShouldRerender()
+	handle.ShouldRerender()
 }
 
-func UseState[T comparable](initialState T) (state T, setState func(T)) {
-	hook, _ := getOrCreateHook(currentHookHost, func() *stateHook[T] {
-		return &stateHook[T]{
-			current: initialState,
-			handle:  currentHookHost.HookCallbacks(),
+// Apply queued actions in the order they were dispatched.
+func (r *reducerHook[S, A]) reduce(reducer func(S, A) S) S {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reducer = reducer
+	for _, action := range r.queue {
+		r.current = reducer(r.current, action)
+	}
+	r.queue = nil
+	return r.current
+}
+
+// UseReducer manages state with a reducer. Actions passed to dispatch are
+// queued, and applied in order using the reducer from the next render, so
+// several dispatches before a render all take effect. dispatch is safe to call
+// from any goroutine, and is the same func for the component's whole lifetime.
+func UseReducer[S comparable, A any](reducer func(S, A) S, initial S) (state S, dispatch func(A)) {
+	host := currentHookHost()
+	hook, _ := getOrCreateHook(host, func() *reducerHook[S, A] {
+		hook := &reducerHook[S, A]{
+			current: initial,
+			equal:   equalComparable[S],
+			handle:  host.HookCallbacks(),
 		}
+		hook.dispatch = hook.Dispatch
+		return hook
 	})
-	return hook.current, hook.SetState
+	return hook.reduce(reducer), hook.dispatch
 }
 
-func UseStateLazy[T comparable](getInitialState func() T) (state T, setState func(T)) {
-	hook, _ := getOrCreateHook(currentHookHost, func() *stateHook[T] {
-		return &stateHook[T]{
-			current: getInitialState(),
-			handle:  currentHookHost.HookCallbacks(),
-		}
+func equalComparable[T comparable](a T, b T) bool {
+	return a == b
+}
+
+// State is a reducer whose actions are updater functions.
+type stateHook[T any] struct {
+	reducerHook[T, func(T) T]
+	set    func(T)
+	update func(func(T) T)
+}
+
+func applyStateUpdate[T any](prev T, update func(T) T) T {
+	return update(prev)
+}
+
+func (state *stateHook[T]) SetState(nextState T) {
+	state.Dispatch(func(T) T { return nextState })
+}
+
+// If equal is nil, every update re-renders.
+func useStateHook[T any](getInitialState func() T, equal func(T, T) bool) (*stateHook[T], T) {
+	host := currentHookHost()
+	hook, _ := getOrCreateHook(host, func() *stateHook[T] {
+		hook := &stateHook[T]{}
+		hook.current = getInitialState()
+		hook.equal = equal
+		hook.handle = host.HookCallbacks()
+		hook.set = hook.SetState
+		hook.update = hook.Dispatch
+		return hook
 	})
-	return hook.current, hook.SetState
+	return hook, hook.reduce(applyStateUpdate[T])
+}
+
+func UseState[T comparable](initialState T) (state T, setState func(T)) {
+	hook, state := useStateHook(func() T { return initialState }, equalComparable[T])
+	return state, hook.set
+}
+
+func UseStateLazy[T comparable](getInitialState func() T) (state T, setState func(T)) {
+	hook, state := useStateHook(getInitialState, equalComparable[T])
+	return state, hook.set
+}
+
+// UseStateFn is UseState, plus an updateState func that computes the next
+// state from the latest state. Updates are queued and applied in order, so
+// updateState(func(n int) int { return n + 1 }) never loses an increment, even
+// when called from several goroutines before the next render.
+func UseStateFn[T comparable](initialState T) (state T, setState func(T), updateState func(update func(prev T) T)) {
+	hook, state := useStateHook(func() T { return initialState }, equalComparable[T])
+	return state, hook.set, hook.update
+}
+
+// UseStateWith is UseState for any type, including slices, maps and structs
+// containing them. Setting state only re-renders if equal(prev, next) is
+// false. If equal is nil, setting state always re-renders, so create a new
+// value rather than mutating the current one.
+func UseStateWith[T any](initialState T, equal func(a T, b T) bool) (state T, setState func(T)) {
+	hook, state := useStateHook(func() T { return initialState }, equal)
+	return state, hook.set
 }
 
 func Default[T any](pointer *T, defaultValue T) T {
@@ -123,6 +304,8 @@ func Some[T any](value T) *T {
 	return &value
 }
 
+// UseCallback returns fn from the render where dependencies last changed. See
+// Always and Once.
 func UseCallback[T any, Deps comparable](fn T, dependencies Deps) T {
 	return UseMemo(func() T { return fn }, dependencies)
 }
@@ -131,45 +314,379 @@ type EffectFunc interface {
 	func() | func() func()
 }
 
+// Effects run after rendering, once the reconciler commits the render to the
+// host. Each phase runs at a different point of the commit.
+type EffectPhase int
+
+const (
+	// Runs before host mutations, eg to inject styles before layout is read.
+	InsertionEffect EffectPhase = iota
+	// Runs synchronously after host mutations, eg to measure host nodes.
+	LayoutEffect
+	// Runs after layout effects. The default.
+	PassiveEffect
+)
+
+// Internal interface between effect hooks and the reconciler's commit.
+// For each phase, the reconciler calls CleanupEffect on every hook in that
+// phase, and then RunEffect, visiting children before parents.
+type EffectHook interface {
+	HookInstance
+	EffectPhase() EffectPhase
+	// Clean up after the previous effect, if a new effect is pending.
+	CleanupEffect()
+	// Run the pending effect, if any.
+	RunEffect()
+}
+
 type effectHook[T EffectFunc, Deps comparable] struct {
-	pending  *T
-	cleanup  *func()
-	prevDeps Deps
-	// TODO: phase (effect/layoutEffect/...)
+	fn        T    // from the latest render
+	deps      Deps // from the latest render
+	pending   bool // if the latest render's deps differ from the committed ones
+	committed bool
+	prevDeps  Deps // of the last effect that ran
+	cleanup   func()
+	phase     EffectPhase
 }
 
 func (effect *effectHook[T, Deps]) Unmount() {
 	if effect.cleanup != nil {
-		(*effect.cleanup)()
+		effect.cleanup()
 		effect.cleanup = nil
 	}
 }
 
-func (effect *effectHook[T, Deps]) mount() {
-	if effect.pending != nil {
-		pending := *effect.pending
+func (effect *effectHook[T, Deps]) EffectPhase() EffectPhase {
+	return effect.phase
+}
+
+func (effect *effectHook[T, Deps]) CleanupEffect() {
+	if effect.pending {
 		effect.Unmount()
+	}
+}
 
-		if withCleanup, ok := any(pending).(func() func()); ok {
-			res := withCleanup()
-			effect.cleanup = &res
-		} else {
-			any(pending).(func())()
+func (effect *effectHook[T, Deps]) RunEffect() {
+	if !effect.pending {
+		return
+	}
+	effect.pending = false
+	effect.committed = true
+	effect.prevDeps = effect.deps
+	if withCleanup, ok := any(effect.fn).(func() func()); ok {
+		effect.cleanup = withCleanup()
+	} else {
+		any(effect.fn).(func())()
+	}
+}
+
+// Like UseMemo, effects compare dependencies with the committed render's, and
+// run fn from the latest render, so a render that's thrown away and run again
+// doesn't leave a stale fn behind.
+func useEffectPhase[T EffectFunc, Deps comparable](phase EffectPhase, fn T, dependencies Deps) {
+	host := currentHookHost()
+	hook, _ := getOrCreateHook(host, func() *effectHook[T, Deps] {
+		return &effectHook[T, Deps]{phase: phase}
+	})
+	hook.fn = fn
+	hook.deps = dependencies
+	hook.pending = !hook.committed || depsChanged(hook.prevDeps, dependencies)
+}
+
+// UseEffect runs fn after the component renders and the result is committed
+// to the host, on mount and whenever dependencies change. See Always and Once.
+// If fn returns a func, it's called to clean up before fn runs again, and when
+// the component unmounts.
+func UseEffect[T EffectFunc, Deps comparable](fn T, dependencies Deps) {
+	useEffectPhase(PassiveEffect, fn, dependencies)
+}
+
+// UseLayoutEffect is UseEffect, but fn runs synchronously right after host
+// mutations, before passive effects and before the render returns. Use it to
+// measure host nodes or synchronize with imperative host APIs.
+func UseLayoutEffect[T EffectFunc, Deps comparable](fn T, dependencies Deps) {
+	useEffectPhase(LayoutEffect, fn, dependencies)
+}
+
+// UseInsertionEffect is UseEffect, but fn runs before host mutations. It's
+// meant for injecting styles before layout effects read the host. Host nodes
+// from this render aren't in place yet, and refs aren't attached.
+func UseInsertionEffect[T EffectFunc, Deps comparable](fn T, dependencies Deps) {
+	useEffectPhase(InsertionEffect, fn, dependencies)
+}
+
+type eventHook[T any] struct {
+	mu      sync.Mutex // the stable func may be called from any goroutine
+	latest  T          // from the latest committed render
+	pending T          // from the latest render
+	stable  T
+}
+
+func (*eventHook[T]) Unmount() {}
+
+// Update during the insertion phase, so layout and passive effects from the
+// same commit already see the new closure.
+func (*eventHook[T]) EffectPhase() EffectPhase {
+	return InsertionEffect
+}
+
+func (*eventHook[T]) CleanupEffect() {}
+
+func (event *eventHook[T]) RunEffect() {
+	event.mu.Lock()
+	defer event.mu.Unlock()
+	event.latest = event.pending
+}
+
+func (event *eventHook[T]) call(args []reflect.Value) []reflect.Value {
+	event.mu.Lock()
+	latest := reflect.ValueOf(event.latest)
+	event.mu.Unlock()
+
+	if latest.Type().IsVariadic() {
+		return latest.CallSlice(args)
+	}
+	return latest.Call(args)
+}
+
+// UseEvent returns a func with a stable identity for the component's whole
+// lifetime, that always calls the fn from the most recently committed render.
+// Use it for event handlers instead of UseCallback: it needs no dependencies,
+// never sees stale props or state, and doesn't break memoization of children
+// it's passed to. fn must be a func.
+//
+// Don't call the returned func while rendering; it would call fn from the
+// previous render.
+func UseEvent[T any](fn T) T {
+	host := currentHookHost()
+	hook, _ := getOrCreateHook(host, func() *eventHook[T] {
+		fnType := reflect.TypeOf(fn)
+		if fnType == nil || fnType.Kind() != reflect.Func {
+			panic(fmt.Errorf("UseEvent: %T is not a func", fn))
 		}
+		hook := &eventHook[T]{latest: fn}
+		hook.stable = reflect.MakeFunc(fnType, hook.call).Interface().(T)
+		return hook
+	})
+	hook.pending = fn
+	return hook.stable
+}
+
+type idHook struct {
+	id string
+}
+
+func (*idHook) Unmount() {}
 
-		effect.pending = nil
+// UseId returns an ID unique within the root and stable for the component's
+// lifetime, for linking elements together, eg a label's htmlFor and an input's
+// id. IDs come from the component's position in the tree, so server and client
+// renders of the same tree agree. Don't use it for list keys.
+func UseId() string {
+	host := currentHookHost()
+	hook, _ := getOrCreateHook(host, func() *idHook {
+		return &idHook{id: host.Identifier()}
+	})
+	return hook.id
+}
+
+// UseClock returns the clock of the component's root. Read time from it
+// instead of package time, so tests can control it.
+func UseClock() Clock {
+	return currentHookHost().Clock()
+}
+
+// Wait for d on clock. Returns false if ctx is done first.
+func sleepContext(ctx context.Context, clock Clock, d time.Duration) bool {
+	timer := clock.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C():
+		return ctx.Err() == nil
 	}
 }
 
-func UseEffect[T EffectFunc, Deps comparable](fn T, dependencies Deps) {
-	hook, found := getOrCreateHook(currentHookHost, func() *effectHook[T, Deps] {
-		return &effectHook[T, Deps]{
-			pending:  &fn,
-			prevDeps: dependencies,
+// UseInterval calls fn every interval, on its own goroutine, until the
+// component unmounts. Changing interval restarts the timer; changing fn
+// doesn't, the latest fn is called. An interval <= 0 pauses it.
+func UseInterval(fn func(), interval time.Duration) {
+	clock := UseClock()
+	tick := UseEvent(fn)
+	UseGo(func(ctx context.Context) {
+		if interval <= 0 {
+			return
+		}
+		for sleepContext(ctx, clock, interval) {
+			tick()
+		}
+	}, interval)
+}
+
+// UseTimeout calls fn once, on its own goroutine, delay after the component
+// mounts, unless it unmounts first. Changing delay restarts the timer; changing
+// fn doesn't, the latest fn is called. A negative delay cancels it.
+func UseTimeout(fn func(), delay time.Duration) {
+	clock := UseClock()
+	fire := UseEvent(fn)
+	UseGo(func(ctx context.Context) {
+		if delay >= 0 && sleepContext(ctx, clock, delay) {
+			fire()
 		}
+	}, delay)
+}
+
+type debounceDeps[T comparable] struct {
+	value T
+	delay time.Duration
+}
+
+// UseDebouncedValue returns value once it has stopped changing for delay, and
+// the previous debounced value until then.
+func UseDebouncedValue[T comparable](value T, delay time.Duration) T {
+	clock := UseClock()
+	debounced, setDebounced := UseState(value)
+	UseGo(func(ctx context.Context) {
+		if sleepContext(ctx, clock, delay) {
+			setDebounced(value)
+		}
+	}, debounceDeps[T]{value, delay})
+	return debounced
+}
+
+// How long UsePersistentState waits for the value to stop changing before
+// writing it to storage.
+const persistentStateDelay = 300 * time.Millisecond
+
+type persistentHook[T any] struct {
+	mu sync.Mutex
+	// Values set, but not yet written to storage, by key. Keeping them by key
+	// lets a value set just before the key changes still be written to its
+	// own key, and not show under the new one.
+	local     map[string]*T
+	changed   chan struct{}
+	callbacks HookCallbacks
+}
+
+func (*persistentHook[T]) Unmount() {}
+
+func (p *persistentHook[T]) set(key string, value T) {
+	p.mu.Lock()
+	if p.local == nil {
+		p.local = make(map[string]*T)
+	}
+	p.local[key] = &value
+	p.mu.Unlock()
+	select {
+	case p.changed <- struct{}{}:
+	default:
+	}
+	p.callbacks.ShouldRerender()
+}
+
+// Value set last at key, if it hasn't been written yet.
+func (p *persistentHook[T]) pending(key string) (value T, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if local := p.local[key]; local != nil {
+		return *local, true
+	}
+	return value, false
+}
+
+// Write the value set last at key, if any, to storage.
+func (p *persistentHook[T]) flush(storage StateStorage, key string) {
+	p.mu.Lock()
+	local := p.local[key]
+	p.mu.Unlock()
+	if local == nil {
+		return
+	}
+	if data, err := json.Marshal(*local); err != nil {
+		Logger.Printf("UsePersistentState(%q): %v", key, err)
+	} else if err := storage.Set(key, data); err != nil {
+		Logger.Printf("UsePersistentState(%q): %v", key, err)
+	}
+	p.mu.Lock()
+	written := p.local[key] == local
+	if written {
+		delete(p.local, key)
+	}
+	p.mu.Unlock()
+	if written {
+		// Render the stored value, even if storage didn't report a change.
+		p.callbacks.ShouldRerender()
+	}
+}
+
+// Write values once they stop changing, and the last one when ctx is done.
+func (p *persistentHook[T]) writeLoop(ctx context.Context, storage StateStorage, clock Clock, key string) {
+	defer p.flush(storage, key)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.changed:
+		}
+		for debouncing := true; debouncing; {
+			timer := clock.NewTimer(persistentStateDelay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-p.changed:
+				timer.Stop()
+			case <-timer.C():
+				p.flush(storage, key)
+				debouncing = false
+			}
+		}
+	}
+}
+
+// UsePersistentState is UseState for state that outlives the process, kept
+// as JSON at key in the root's StateStorage. Writes wait until the value stops
+// changing for a moment, and are flushed when the component unmounts. When
+// the storage reports key changed, eg another process wrote the file, the
+// component re-renders with the stored value.
+func UsePersistentState[T any](key string, initial T) (state T, setState func(T)) {
+	host := currentHookHost()
+	storage := host.Storage()
+	clock := UseClock()
+	hook, _ := getOrCreateHook(host, func() *persistentHook[T] {
+		return &persistentHook[T]{changed: make(chan struct{}, 1), callbacks: host.HookCallbacks()}
+	})
+	deps := Deps(storage, key)
+
+	subscribe := UseCallback(func(onChange func()) func() {
+		return storage.Subscribe(key, onChange)
+	}, deps)
+	stored := UseSyncExternalStore(subscribe, func() string {
+		data, _ := storage.Get(key)
+		return string(data)
 	})
+	storedValue := UseMemo(func() T {
+		if stored == "" {
+			return initial
+		}
+		var value T
+		if err := json.Unmarshal([]byte(stored), &value); err != nil {
+			Logger.Printf("UsePersistentState(%q): %v", key, err)
+			return initial
+		}
+		return value
+	}, stored)
+
+	UseGo(func(ctx context.Context) {
+		hook.writeLoop(ctx, storage, clock, key)
+	}, deps)
 
-	if !found && dependencies != hook.prevDeps {
-		hook.pending = &fn
+	setState = UseCallback(func(value T) {
+		hook.set(key, value)
+	}, key)
+	if local, ok := hook.pending(key); ok {
+		return local, setState
 	}
+	return storedValue, setState
 }
@@ -0,0 +1,82 @@
+package react
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// Components may implement DisplayNamer to control how they appear in logs
+// and errors.
+type DisplayNamer interface {
+	DisplayName() string
+}
+
+// DisplayName returns a human readable name for a component.
+func DisplayName(comp any) string {
+	if comp == nil {
+		return "nil"
+	}
+	if named, ok := comp.(DisplayNamer); ok {
+		return named.DisplayName()
+	}
+	value := reflect.ValueOf(comp)
+	if value.Kind() == reflect.Func && !value.IsNil() {
+		if fn := runtime.FuncForPC(value.Pointer()); fn != nil {
+			return shortFuncName(fn.Name())
+		}
+	}
+	return value.Type().String()
+}
+
+// "github.com/justjake/react4c/main.init.func1" -> "main.init.func1"
+func shortFuncName(name string) string {
+	if slash := strings.LastIndexByte(name, '/'); slash >= 0 {
+		return name[slash+1:]
+	}
+	return name
+}
+
+// SameComponent reports if a and b are the same component, meaning a fiber
+// rendering a can be re-used to render b. It never panics, even for
+// components that aren't comparable with ==.
+//
+//   - Components of different types are never the same.
+//   - Comparable components (pointers, structs like HtmlTag, ...) are compared with ==.
+//     If == panics because they hold funcs in interfaces, they're compared like
+//     dependencies instead, so funcs are the same if they're the same closure.
+//   - Funcs are compared by code pointer. Note that closures created by the same
+//     func literal share a code pointer; use FunctionComponent to get a
+//     distinct identity per component.
+//   - Other uncomparable values are the same if their types are the same.
+func SameComponent(a, b any) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if memo, ok := a.(MemoComponent); ok {
+		return memo.SameComponent(b)
+	}
+
+	typ := reflect.TypeOf(a)
+	if typ != reflect.TypeOf(b) {
+		return false
+	}
+	if typ.Kind() == reflect.Func {
+		return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+	}
+	if typ.Comparable() {
+		return comparableEqual(a, b)
+	}
+	return true
+}
+
+// Types like struct{ Render any } are comparable, but == panics if the
+// interface holds a func.
+func comparableEqual(a, b any) (equal bool) {
+	defer func() {
+		if recover() != nil {
+			equal = depValueEqual(a, b)
+		}
+	}()
+	return a == b
+}
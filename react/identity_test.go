@@ -0,0 +1,37 @@
+package react
+
+import "testing"
+
+// Comparable, but == panics when render holds a func.
+type dynamicComponent struct {
+	render any
+}
+
+func (c dynamicComponent) Render(props WithKey) AnyNode {
+	return c.render.(func() AnyNode)()
+}
+
+func renderNothing(WithKey) AnyNode { return nil }
+func renderText(WithKey) AnyNode    { return Text("text") }
+
+func TestSameComponent(t *testing.T) {
+	first := func() AnyNode { return Text("first") }
+	second := func() AnyNode { return Text("second") }
+	cases := []struct {
+		name string
+		a, b any
+		want bool
+	}{
+		{"same ComponentFunc", ComponentFunc[WithKey](renderNothing), ComponentFunc[WithKey](renderNothing), true},
+		{"different ComponentFuncs", ComponentFunc[WithKey](renderNothing), ComponentFunc[WithKey](renderText), false},
+		{"holding the same func", dynamicComponent{first}, dynamicComponent{first}, true},
+		{"holding different funcs", dynamicComponent{first}, dynamicComponent{second}, false},
+		{"holding a func and a string", dynamicComponent{first}, dynamicComponent{"first"}, false},
+		{"different types", ComponentFunc[WithKey](renderNothing), dynamicComponent{first}, false},
+	}
+	for _, c := range cases {
+		if got := SameComponent(c.a, c.b); got != c.want {
+			t.Errorf("%s: SameComponent() = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
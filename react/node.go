@@ -45,18 +45,22 @@ func (n *Node[Props]) GetChildren() []AnyNode {
 	return n.Children
 }
 
-// Create a Node
+// Create a Node. Children passed here replace any children already in props;
+// if none are passed, the node keeps the children in props.
 func JSX[Props IProps](comp Component[Props], props Props, children ...AnyNode) *Node[Props] {
-	// var s ChildrenSetter
-	// var boxProps BoxProps
-
-	// s = &boxProps
-
-	// _ = any(props).(ChildrenSetter)
-	if settable, ok := any(&props).(ChildrenSetter); ok {
-		settable.SetChildren(children)
+	if fn, ok := funcChildOf(children); ok {
+		if settable, ok := any(&props).(ChildrenFuncSetter); !ok || !settable.SetChildrenFunc(fn) {
+			Logger.Printf("JSX: can't set function child %T on %T", fn, props)
+		}
+		children = nil
 	} else if len(children) > 0 {
-		Logger.Printf("JSX: can't set children on %T: %#v <-x- %v", props, props, children)
+		if settable, ok := any(&props).(ChildrenSetter); ok {
+			settable.SetChildren(children)
+		} else {
+			Logger.Printf("JSX: can't set children on %T: %#v <-x- %v", props, props, children)
+		}
+	} else {
+		children = GetChildren(&props)
 	}
 
 	node := Node[Props]{
@@ -70,3 +74,38 @@ func JSX[Props IProps](comp Component[Props], props Props, children ...AnyNode)
 
 	return &node
 }
+
+type funcChildProps struct {
+	WithKey
+	fn any
+}
+
+type funcChildComponent struct{}
+
+func (funcChildComponent) Render(props funcChildProps) AnyNode {
+	Logger.Printf("FuncChild: %T rendered outside of JSX, pass it as the only child of a component with ChildrenFunc props", props.fn)
+	return nil
+}
+
+func (funcChildComponent) DisplayName() string {
+	return "FuncChild"
+}
+
+// FuncChild wraps fn, so it can be passed to JSX as the only child of a
+// component whose props embed ChildrenFunc[T].
+//
+//	JSX(MouseTracker, MouseTrackerProps{}, FuncChild(func(pos Point) AnyNode {
+//		return Text.F("%d, %d", pos.X, pos.Y)
+//	}))
+func FuncChild[T any](fn func(T) AnyNode) AnyNode {
+	return JSX[funcChildProps](funcChildComponent{}, funcChildProps{fn: fn})
+}
+
+// Returns the function if children is a single FuncChild.
+func funcChildOf(children []AnyNode) (fn any, ok bool) {
+	if len(children) != 1 || IsEmpty(children[0]) {
+		return nil, false
+	}
+	props, ok := children[0].GetProps().(funcChildProps)
+	return props.fn, ok
+}
@@ -64,6 +64,35 @@ func (c *WithChildren) SetChildren(newChildren []AnyNode) {
 	c.Children = newChildren
 }
 
+// ChildrenFunc is embedded in the props of components that take a function
+// as their child, and call it to render with their state. Pass the function
+// with FuncChild, or set Children directly.
+type ChildrenFunc[T any] struct {
+	Children func(T) AnyNode
+}
+
+// RenderChildren calls the function child with value. Renders nothing if
+// there's no function child.
+func (c ChildrenFunc[T]) RenderChildren(value T) AnyNode {
+	if c.Children == nil {
+		return nil
+	}
+	return c.Children(value)
+}
+
+func (c *ChildrenFunc[T]) SetChildrenFunc(fn any) bool {
+	typed, ok := fn.(func(T) AnyNode)
+	if ok {
+		c.Children = typed
+	}
+	return ok
+}
+
+type ChildrenFuncSetter interface {
+	// Set the function child. Returns false if fn has the wrong type.
+	SetChildrenFunc(fn any) bool
+}
+
 type WithRef[T any] struct {
 	Ref Ref[*T]
 }
@@ -72,6 +101,21 @@ func (wr *WithRef[T]) GetRef() Ref[*T] {
 	return wr.Ref
 }
 
+func (wr WithRef[T]) RefTarget() any {
+	if wr.Ref == nil {
+		return nil
+	}
+	return wr.Ref
+}
+
+func (wr WithRef[T]) SetRefInstance(instance any) {
+	if wr.Ref == nil {
+		return
+	}
+	typed, _ := instance.(*T)
+	wr.Ref.Set(typed)
+}
+
 type HasRef[T any] interface {
 	GetRef() Ref[T]
 }
@@ -0,0 +1,295 @@
+package react
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// QueryCache holds the results of UseQuery, shared by every component below
+// its provider. Components asking for the same key share one fetch and one
+// result. Usually there's one QueryCache per root.
+type QueryCache struct {
+	mu      sync.Mutex
+	entries map[string]*queryEntry
+}
+
+type queryEntry struct {
+	state       *queryState // replaced, never mutated, so it works as a snapshot
+	subscribers map[int]func()
+	nextID      int
+	invalidated bool
+
+	// Mounted components using the key, latest last. The latest one's fetcher
+	// is used for refetching.
+	observers []*queryObserver
+
+	fetchID int // identifies the fetch in flight; late results are ignored
+	cancel  context.CancelFunc
+}
+
+type queryObserver struct {
+	fetch func(ctx context.Context) (any, error)
+	// Runs the fetch in a goroutine owned by the component's root.
+	spawn func(func())
+	// The root's clock, which dates the results it fetches.
+	clock Clock
+}
+
+type queryState struct {
+	value     any
+	err       error
+	hasResult bool
+	fetching  bool
+	updatedAt time.Time
+}
+
+func NewQueryCache() *QueryCache {
+	return &QueryCache{entries: make(map[string]*queryEntry)}
+}
+
+// QueryCacheContext provides the QueryCache that UseQuery reads.
+var QueryCacheContext = CreateContext[*QueryCache](nil)
+
+// QueryCacheProvider makes cache available to UseQuery in children.
+func QueryCacheProvider(cache *QueryCache, children ...AnyNode) AnyNode {
+	return QueryCacheContext.Provide(cache, children...)
+}
+
+// Must hold cache.mu.
+func (cache *QueryCache) entry(key string) *queryEntry {
+	entry, ok := cache.entries[key]
+	if !ok {
+		entry = &queryEntry{state: &queryState{}, subscribers: make(map[int]func())}
+		cache.entries[key] = entry
+	}
+	return entry
+}
+
+// Set stores a fresh result for key, eg to fill the cache before server
+// rendering, so the HTML already contains the data. It's dated by the clock of
+// the latest component using key, or the real clock if there's none.
+func (cache *QueryCache) Set(key string, value any) {
+	cache.finish(key, nil, nil, value, nil)
+}
+
+// PrefetchQuery calls fetcher and stores its result for key. Use it before
+// server rendering, since UseQuery doesn't fetch while rendering to a string.
+func PrefetchQuery[T any](ctx context.Context, cache *QueryCache, key string, fetcher func(ctx context.Context) (T, error)) error {
+	value, err := fetcher(ctx)
+	if err != nil {
+		return err
+	}
+	cache.Set(key, value)
+	return nil
+}
+
+// Invalidate marks the result for key stale. If components are using it, it's
+// fetched again right away, replacing any fetch in flight.
+func (cache *QueryCache) Invalidate(key string) {
+	cache.mu.Lock()
+	entry := cache.entry(key)
+	entry.invalidated = true
+	var notify []func()
+	if len(entry.subscribers) > 0 && len(entry.observers) > 0 {
+		notify = cache.startFetch(key, entry)
+	}
+	cache.mu.Unlock()
+	for _, onChange := range notify {
+		onChange()
+	}
+}
+
+func (cache *QueryCache) snapshot(key string) *queryState {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	return cache.entry(key).state
+}
+
+func (cache *QueryCache) subscribe(key string, onChange func()) func() {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	entry := cache.entry(key)
+	id := entry.nextID
+	entry.nextID++
+	entry.subscribers[id] = onChange
+	return func() {
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+		delete(entry.subscribers, id)
+		if len(entry.subscribers) == 0 && entry.state.fetching {
+			// Nobody is waiting for the result.
+			entry.cancel()
+			entry.fetchID++
+			next := *entry.state
+			next.fetching = false
+			entry.state = &next
+		}
+	}
+}
+
+// Remember how to fetch key, for Invalidate, until unobserve is called. Once
+// a component unmounts, its root may be gone, so fetches must not use it.
+func (cache *QueryCache) observe(key string, fetch func(ctx context.Context) (any, error), spawn func(func()), clock Clock) (unobserve func()) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	entry := cache.entry(key)
+	observer := &queryObserver{fetch: fetch, spawn: spawn, clock: clock}
+	entry.observers = append(entry.observers, observer)
+	return func() {
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+		for i, o := range entry.observers {
+			if o == observer {
+				entry.observers = append(entry.observers[:i], entry.observers[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// Fetch key unless its result is fresh on clock or a fetch is already in
+// flight.
+func (cache *QueryCache) fetchIfStale(key string, staleTime time.Duration, clock Clock) {
+	cache.mu.Lock()
+	entry := cache.entry(key)
+	state := entry.state
+	var notify []func()
+	fresh := state.hasResult && !entry.invalidated && clock.Now().Sub(state.updatedAt) < staleTime
+	if !fresh && !state.fetching && len(entry.observers) > 0 {
+		notify = cache.startFetch(key, entry)
+	}
+	cache.mu.Unlock()
+	for _, onChange := range notify {
+		onChange()
+	}
+}
+
+// Start fetching key with the latest observer, replacing any fetch in flight.
+// Must hold cache.mu, and entry must have an observer. Returns the subscribers
+// to notify once the lock is released.
+func (cache *QueryCache) startFetch(key string, entry *queryEntry) []func() {
+	if entry.state.fetching {
+		entry.cancel()
+	}
+	entry.fetchID++
+	fetchID := entry.fetchID
+	ctx, cancel := context.WithCancel(context.Background())
+	entry.cancel = cancel
+	next := *entry.state
+	next.fetching = true
+	entry.state = &next
+
+	observer := entry.observers[len(entry.observers)-1]
+	observer.spawn(func() {
+		value, err := observer.fetch(ctx)
+		cancel()
+		cache.finish(key, &fetchID, observer.clock, value, err)
+	})
+	return entry.notifyList()
+}
+
+// Store a result for key, dated by clock, or by the latest observer's clock if
+// nil. If fetchID is set, the result is ignored unless it's from the latest
+// fetch. A failed fetch keeps the previous value.
+func (cache *QueryCache) finish(key string, fetchID *int, clock Clock, value any, err error) {
+	cache.mu.Lock()
+	entry := cache.entry(key)
+	if fetchID != nil && *fetchID != entry.fetchID {
+		cache.mu.Unlock()
+		return
+	}
+	if fetchID == nil && entry.state.fetching {
+		// Replaces the fetch in flight.
+		entry.cancel()
+		entry.fetchID++
+	}
+	if clock == nil {
+		clock = RealClock{}
+		if len(entry.observers) > 0 {
+			clock = entry.observers[len(entry.observers)-1].clock
+		}
+	}
+	next := &queryState{value: value, err: err, hasResult: true, updatedAt: clock.Now()}
+	if err != nil {
+		next.value = entry.state.value
+	}
+	entry.state = next
+	entry.invalidated = false
+	notify := entry.notifyList()
+	cache.mu.Unlock()
+	for _, onChange := range notify {
+		onChange()
+	}
+}
+
+func (entry *queryEntry) notifyList() []func() {
+	notify := make([]func(), 0, len(entry.subscribers))
+	for _, onChange := range entry.subscribers {
+		notify = append(notify, onChange)
+	}
+	return notify
+}
+
+type QueryOptions struct {
+	// How long a result stays fresh after it's fetched. Components that mount
+	// while the result is stale still get it, and it's fetched again in the
+	// background. Zero means results are stale right away.
+	StaleTime time.Duration
+}
+
+// QueryResult is the state of a query in the QueryCache.
+type QueryResult[T any] struct {
+	Value T
+	// Error from the latest fetch. Value keeps the last successful result.
+	Err error
+	// True until the first result arrives.
+	Loading bool
+	// True while a fetch is in flight, including background refetches.
+	Fetching bool
+}
+
+type queryDeps struct {
+	cache *QueryCache
+	key   string
+}
+
+// UseQuery returns the cached result for key from the QueryCache provided
+// above the component, fetching it with fetcher if it's missing or stale. The
+// component re-renders when the result changes, eg after Invalidate.
+func UseQuery[T any](key string, fetcher func(ctx context.Context) (T, error), options QueryOptions) QueryResult[T] {
+	host := currentHookHost()
+	clock := UseClock()
+	cache := UseContext(QueryCacheContext)
+	if cache == nil {
+		panic(fmt.Errorf("UseQuery(%q): no QueryCache provided, wrap the tree in QueryCacheProvider", key))
+	}
+	deps := queryDeps{cache, key}
+
+	subscribe := UseCallback(func(onChange func()) func() {
+		return cache.subscribe(key, onChange)
+	}, deps)
+	state := UseSyncExternalStore(subscribe, func() *queryState {
+		return cache.snapshot(key)
+	})
+
+	UseEffect(func() func() {
+		return cache.observe(key, func(ctx context.Context) (any, error) {
+			return fetcher(ctx)
+		}, host.Go, clock)
+	}, Always{})
+	UseEffect(func() {
+		cache.fetchIfStale(key, options.StaleTime, clock)
+	}, deps)
+
+	result := QueryResult[T]{Err: state.err, Loading: !state.hasResult, Fetching: state.fetching}
+	if state.value != nil {
+		value, ok := state.value.(T)
+		if !ok {
+			panic(fmt.Errorf("UseQuery(%q): cached value is %T, not %T", key, state.value, result.Value))
+		}
+		result.Value = value
+	}
+	return result
+}
@@ -7,3 +7,17 @@ type Ref[T any] interface {
 type RefStruct[T any] struct {
 	Current T
 }
+
+func (r *RefStruct[T]) Set(val T) {
+	r.Current = val
+}
+
+// RefSetter lets the reconciler attach host instances to typed refs in props
+// without knowing their type. Implemented by WithRef.
+type RefSetter interface {
+	// Set the ref to instance, or to nil if instance is nil or has the wrong type.
+	SetRefInstance(instance any)
+	// The ref instances are set on, or nil. The reconciler only sets the ref
+	// again when it changes.
+	RefTarget() any
+}
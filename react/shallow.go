@@ -0,0 +1,208 @@
+package react
+
+import (
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+// ShallowEqual reports if a and b are equal one level deep. It's meant for
+// comparing props, and works for values that can't be compared with ==.
+//
+// For structs, each field is compared, with embedded structs treated as part of
+// the outer struct:
+//
+//   - Pointers are equal if they point to equal values, so two distinct
+//     Some("red") are equal.
+//   - Slices are equal if they have the same length and identical elements, so
+//     children are equal if they're the same nodes.
+//   - Funcs are equal if they're the same closure. Closures created by separate
+//     evaluations of a func literal differ if they capture variables.
+//   - Maps and channels are equal if they're the same map or channel.
+//   - Everything else is compared as if by ==.
+//
+// Values that aren't structs are compared the same way as a single field.
+func ShallowEqual(a, b any) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if !va.IsValid() || !vb.IsValid() {
+		return va.IsValid() == vb.IsValid()
+	}
+	typ := va.Type()
+	if typ != vb.Type() {
+		return false
+	}
+	if mayHoldFuncs(typ) {
+		va, vb = addressable(va), addressable(vb)
+	}
+	if typ.Kind() != reflect.Struct {
+		return shallowFieldEqual(va, vb)
+	}
+
+	layout := structLayoutOf(typ)
+	for _, index := range layout.fields {
+		if !shallowFieldEqual(va.FieldByIndex(index), vb.FieldByIndex(index)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Fields compared by ShallowEqual for a struct type, with embedded structs
+// flattened.
+type structLayout struct {
+	fields [][]int // indexes for FieldByIndex
+}
+
+var structLayouts sync.Map // reflect.Type -> *structLayout
+
+func structLayoutOf(typ reflect.Type) *structLayout {
+	if cached, ok := structLayouts.Load(typ); ok {
+		return cached.(*structLayout)
+	}
+	layout := &structLayout{}
+	layout.add(typ, nil)
+	cached, _ := structLayouts.LoadOrStore(typ, layout)
+	return cached.(*structLayout)
+}
+
+func (layout *structLayout) add(typ reflect.Type, parent []int) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		index := append(append([]int{}, parent...), i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			layout.add(field.Type, index)
+			continue
+		}
+		layout.fields = append(layout.fields, index)
+	}
+}
+
+var funcHolders sync.Map // reflect.Type -> bool
+
+// Reports if values of typ may contain funcs that identical reaches without
+// going through a pointer or slice, which are always addressable. Closure
+// identity is read from the func's address, so these values are copied to
+// make them addressable before comparing.
+func mayHoldFuncs(typ reflect.Type) bool {
+	if cached, ok := funcHolders.Load(typ); ok {
+		return cached.(bool)
+	}
+	holds := false
+	switch typ.Kind() {
+	case reflect.Func, reflect.Interface:
+		holds = true
+	case reflect.Array:
+		holds = mayHoldFuncs(typ.Elem())
+	case reflect.Struct:
+		for i := 0; i < typ.NumField() && !holds; i++ {
+			holds = mayHoldFuncs(typ.Field(i).Type)
+		}
+	}
+	funcHolders.Store(typ, holds)
+	return holds
+}
+
+// Values reached through unexported fields are read-only, and can't be copied
+// by reflect. Addressable ones can be re-read without the flag.
+func exported(v reflect.Value) reflect.Value {
+	if v.CanInterface() || !v.CanAddr() {
+		return v
+	}
+	return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+}
+
+func addressable(v reflect.Value) reflect.Value {
+	if v.CanAddr() {
+		return v
+	}
+	copied := reflect.New(v.Type()).Elem()
+	copied.Set(v)
+	return copied
+}
+
+func shallowFieldEqual(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Pointer:
+		if a.Pointer() == b.Pointer() {
+			return true
+		}
+		if a.IsNil() || b.IsNil() {
+			return false
+		}
+		return identical(a.Elem(), b.Elem())
+	}
+	return identical(a, b)
+}
+
+// Values are identical if they're equal as if by ==, except that slices, maps
+// and funcs compare by identity rather than panicking.
+func identical(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Bool:
+		return a.Bool() == b.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() == b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() == b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() == b.Float()
+	case reflect.Complex64, reflect.Complex128:
+		return a.Complex() == b.Complex()
+	case reflect.String:
+		return a.String() == b.String()
+	case reflect.Pointer, reflect.UnsafePointer, reflect.Chan, reflect.Map:
+		return a.Pointer() == b.Pointer()
+	case reflect.Func:
+		return funcIdentity(a) == funcIdentity(b)
+	case reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		if a.Elem().Type() != b.Elem().Type() {
+			return false
+		}
+		ae, be := a.Elem(), b.Elem()
+		if mayHoldFuncs(ae.Type()) {
+			// Interface elements aren't addressable.
+			ae, be = addressable(exported(a).Elem()), addressable(exported(b).Elem())
+		}
+		return identical(ae, be)
+	case reflect.Slice:
+		if a.Len() != b.Len() {
+			return false
+		}
+		if a.Pointer() == b.Pointer() {
+			return true
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !identical(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Array:
+		for i := 0; i < a.Len(); i++ {
+			if !identical(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if !identical(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// A func value is a pointer to a closure object, which is distinct for each
+// closure that captures variables. reflect only exposes the closure's code
+// pointer, which is shared by every closure created by a func literal, so we
+// read the closure pointer from the func's address. See mayHoldFuncs.
+func funcIdentity(fn reflect.Value) uintptr {
+	fn = addressable(fn)
+	return uintptr(*(*unsafe.Pointer)(unsafe.Pointer(fn.UnsafeAddr())))
+}
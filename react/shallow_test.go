@@ -0,0 +1,113 @@
+package react
+
+import "testing"
+
+func makeAdder(n int) func(int) int {
+	return func(x int) int { return x + n }
+}
+
+type namedFuncProps struct {
+	WithKey
+	OnClick func(int) int
+	Label   string
+}
+
+type unexportedFuncProps struct {
+	WithKey
+	onClick func(int) int
+}
+
+type anyProps struct {
+	WithKey
+	Handler any
+}
+
+type nestedProps struct {
+	WithKey
+	Handlers struct {
+		OnClick func(int) int
+	}
+}
+
+type arrayProps struct {
+	WithKey
+	Handlers [2]func(int) int
+}
+
+func TestShallowEqualFuncs(t *testing.T) {
+	add1, add2 := makeAdder(1), makeAdder(2)
+	nested := func(fn func(int) int) nestedProps {
+		var props nestedProps
+		props.Handlers.OnClick = fn
+		return props
+	}
+	tests := []struct {
+		name string
+		a, b any
+		want bool
+	}{
+		{"same closure", add1, add1, true},
+		{"closures of the same literal", add1, add2, false},
+		{"nil funcs", (func())(nil), (func())(nil), true},
+		{"struct field, same closure", namedFuncProps{OnClick: add1}, namedFuncProps{OnClick: add1}, true},
+		{"struct field, other closure", namedFuncProps{OnClick: add1}, namedFuncProps{OnClick: add2}, false},
+		{"unexported field, same closure", unexportedFuncProps{onClick: add1}, unexportedFuncProps{onClick: add1}, true},
+		{"unexported field, other closure", unexportedFuncProps{onClick: add1}, unexportedFuncProps{onClick: add2}, false},
+		{"any field, same closure", anyProps{Handler: add1}, anyProps{Handler: add1}, true},
+		{"any field, other closure", anyProps{Handler: add1}, anyProps{Handler: add2}, false},
+		{"nested struct, same closure", nested(add1), nested(add1), true},
+		{"nested struct, other closure", nested(add1), nested(add2), false},
+		{"array, same closures", arrayProps{Handlers: [2]func(int) int{add1, add2}}, arrayProps{Handlers: [2]func(int) int{add1, add2}}, true},
+		{"array, other closures", arrayProps{Handlers: [2]func(int) int{add1, add2}}, arrayProps{Handlers: [2]func(int) int{add2, add1}}, false},
+		{"pointer to struct, other closure", &namedFuncProps{OnClick: add1}, &namedFuncProps{OnClick: add2}, false},
+		{"pointer to equal structs", &namedFuncProps{Label: "a"}, &namedFuncProps{Label: "a"}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := ShallowEqual(test.a, test.b); got != test.want {
+				t.Errorf("ShallowEqual() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDepsFuncs(t *testing.T) {
+	add1, add2 := makeAdder(1), makeAdder(2)
+	items := []int{1, 2}
+	tests := []struct {
+		name       string
+		prev, next *DepList
+		want       bool
+	}{
+		{"same closure", Deps(add1), Deps(add1), true},
+		{"closures of the same literal", Deps(add1), Deps(add2), false},
+		{"func in struct", Deps(namedFuncProps{OnClick: add1}), Deps(namedFuncProps{OnClick: add2}), false},
+		{"func in any field", Deps(anyProps{Handler: add1}), Deps(anyProps{Handler: add1}), true},
+		{"same slice", Deps(items), Deps(items), true},
+		{"appended slice", Deps(items), Deps(append(items[:1:1], 2)), false},
+		{"different lengths", Deps(1), Deps(1, 2), false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.next.DepsEqual(test.prev); got != test.want {
+				t.Errorf("DepsEqual() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSnapshotFuncs(t *testing.T) {
+	add1, add2 := makeAdder(1), makeAdder(2)
+	current := add1
+	store := &externalStoreHook[func(int) int]{
+		getSnapshot: func() func(int) int { return current },
+		snapshot:    add1,
+	}
+	if store.SnapshotChanged() {
+		t.Errorf("SnapshotChanged() with the same closure")
+	}
+	current = add2
+	if !store.SnapshotChanged() {
+		t.Errorf("SnapshotChanged() missed a different closure")
+	}
+}
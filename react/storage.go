@@ -0,0 +1,243 @@
+package react
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// StateStorage stores the values of UsePersistentState as JSON, by key.
+// Implementations must be safe to use from many goroutines.
+type StateStorage interface {
+	Get(key string) (data []byte, ok bool)
+	Set(key string, data []byte) error
+	// Subscribe calls onChange, from any goroutine, whenever the data at key
+	// changes. Returns a func that stops calling it.
+	Subscribe(key string, onChange func()) (unsubscribe func())
+}
+
+// MemoryStorage is a StateStorage that keeps values in memory.
+type MemoryStorage struct {
+	mu          sync.Mutex
+	values      map[string][]byte
+	subscribers map[string]map[int]func()
+	nextID      int
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		values:      make(map[string][]byte),
+		subscribers: make(map[string]map[int]func()),
+	}
+}
+
+func (s *MemoryStorage) Get(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.values[key]
+	return data, ok
+}
+
+func (s *MemoryStorage) Set(key string, data []byte) error {
+	s.replace(map[string][]byte{key: data}, nil)
+	return nil
+}
+
+func (s *MemoryStorage) Subscribe(key string, onChange func()) func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.nextID
+	s.nextID++
+	if s.subscribers[key] == nil {
+		s.subscribers[key] = make(map[int]func())
+	}
+	s.subscribers[key][id] = onChange
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.subscribers[key], id)
+	}
+}
+
+// Store values, delete the deleted keys, and notify subscribers of the keys
+// whose data changed.
+func (s *MemoryStorage) replace(values map[string][]byte, deleted []string) {
+	s.mu.Lock()
+	var notify []func()
+	changed := func(key string) {
+		for _, onChange := range s.subscribers[key] {
+			notify = append(notify, onChange)
+		}
+	}
+	for key, data := range values {
+		if prev, ok := s.values[key]; !ok || !bytes.Equal(prev, data) {
+			s.values[key] = append([]byte(nil), data...)
+			changed(key)
+		}
+	}
+	for _, key := range deleted {
+		if _, ok := s.values[key]; ok {
+			delete(s.values, key)
+			changed(key)
+		}
+	}
+	s.mu.Unlock()
+	for _, onChange := range notify {
+		onChange()
+	}
+}
+
+func (s *MemoryStorage) snapshot() map[string]json.RawMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	values := make(map[string]json.RawMessage, len(s.values))
+	for key, data := range s.values {
+		values[key] = data
+	}
+	return values
+}
+
+// FileStorage is a StateStorage kept in a JSON file, so state survives
+// restarts. Every Set rewrites the file. Call Reload or Watch to pick up
+// changes made by other processes.
+type FileStorage struct {
+	memory  *MemoryStorage
+	path    string
+	writeMu sync.Mutex
+	modTime time.Time
+}
+
+// NewFileStorage loads the storage at path. The file is created on the first
+// Set if it doesn't exist.
+func NewFileStorage(path string) (*FileStorage, error) {
+	s := &FileStorage{memory: NewMemoryStorage(), path: path}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileStorage) Get(key string) ([]byte, bool) {
+	return s.memory.Get(key)
+}
+
+func (s *FileStorage) Set(key string, data []byte) error {
+	if !json.Valid(data) {
+		return fmt.Errorf("FileStorage.Set(%q): invalid JSON", key)
+	}
+	s.memory.Set(key, data)
+	return s.save()
+}
+
+func (s *FileStorage) Subscribe(key string, onChange func()) func() {
+	return s.memory.Subscribe(key, onChange)
+}
+
+// Write the file atomically, so readers never see it half written.
+func (s *FileStorage) save() error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	data, err := json.MarshalIndent(s.memory.snapshot(), "", "  ")
+	if err != nil {
+		return err
+	}
+	temp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(temp.Name(), s.path); err != nil {
+		return err
+	}
+	if info, err := os.Stat(s.path); err == nil {
+		s.modTime = info.ModTime()
+	}
+	return nil
+}
+
+// Reload reads the file again, notifying subscribers of keys that changed.
+func (s *FileStorage) Reload() error {
+	s.writeMu.Lock()
+	values, err := s.read()
+	s.writeMu.Unlock()
+	if err != nil {
+		return err
+	}
+	s.load(values)
+	return nil
+}
+
+// Read the file, and remember its modification time. Must hold writeMu.
+func (s *FileStorage) read() (map[string]json.RawMessage, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		data, err = []byte("{}"), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(s.path); err == nil {
+		s.modTime = info.ModTime()
+	}
+	return values, nil
+}
+
+// Replace the values in memory with values read from the file. Subscribers
+// may render synchronously and Set, so this must not hold writeMu.
+func (s *FileStorage) load(values map[string]json.RawMessage) {
+	next := make(map[string][]byte, len(values))
+	for key, value := range values {
+		next[key] = value
+	}
+	var deleted []string
+	for key := range s.memory.snapshot() {
+		if _, ok := next[key]; !ok {
+			deleted = append(deleted, key)
+		}
+	}
+	s.memory.replace(next, deleted)
+}
+
+// Watch reloads the file whenever its modification time changes, checking
+// every interval, until ctx is done. Reload errors are logged.
+func (s *FileStorage) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		info, err := os.Stat(s.path)
+		if err != nil {
+			continue
+		}
+		s.writeMu.Lock()
+		modified := !info.ModTime().Equal(s.modTime)
+		s.writeMu.Unlock()
+		if modified {
+			if err := s.Reload(); err != nil {
+				Logger.Printf("FileStorage.Watch(%s): %v", s.path, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package react
+
+import "context"
+
+// UseGo runs worker in a new goroutine after the component commits. Its ctx
+// is cancelled when the component unmounts or when dependencies change, before
+// the next worker starts. worker must return once ctx is done: unmounting the
+// root waits for every worker to exit.
+func UseGo[Deps comparable](worker func(ctx context.Context), dependencies Deps) {
+	host := currentHookHost()
+	UseEffect(func() func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		host.Go(func() {
+			worker(ctx)
+		})
+		return cancel
+	}, dependencies)
+}
+
+// UseChannel returns the latest value received from ch, or initial until the
+// first value arrives. Each value received re-renders the component. Receiving
+// stops when the component unmounts, ch changes, or ch is closed.
+func UseChannel[T any](ch <-chan T, initial T) T {
+	value, setValue := UseStateWith(initial, nil)
+	UseGo(func(ctx context.Context) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case next, ok := <-ch:
+				if !ok {
+					return
+				}
+				setValue(next)
+			}
+		}
+	}, ch)
+	return value
+}
+
+// AsyncResult is the state of the latest call made by UseAsync.
+type AsyncResult[T any] struct {
+	Value   T
+	Err     error
+	Loading bool
+}
+
+type asyncState[T any] struct {
+	result AsyncResult[T]
+	call   int // The call that result belongs to
+}
+
+// Re-render when a call starts or finishes. Superseded calls change nothing.
+func asyncStateEqual[T any](a asyncState[T], b asyncState[T]) bool {
+	return a.call == b.call && a.result.Loading == b.result.Loading
+}
+
+// UseAsync calls fn in a new goroutine after the component commits, and again
+// whenever dependencies change, re-rendering when the call finishes.
+//
+// While a call is running, Loading is true and Value and Err hold the previous
+// call's result, if any. Starting a new call or unmounting cancels the
+// previous call's ctx, and results from superseded calls are ignored. fn
+// should return once ctx is done: unmounting the root waits for it.
+func UseAsync[T any, Deps comparable](fn func(ctx context.Context) (T, error), dependencies Deps) AsyncResult[T] {
+	host := currentHookHost()
+	hook, state := useStateHook(func() asyncState[T] {
+		return asyncState[T]{result: AsyncResult[T]{Loading: true}}
+	}, asyncStateEqual[T])
+	calls := UseRefInitial(0)
+
+	UseEffect(func() func() {
+		calls.Current++
+		call := calls.Current
+		ctx, cancel := context.WithCancel(context.Background())
+		hook.update(func(prev asyncState[T]) asyncState[T] {
+			prev.result.Loading = true
+			prev.call = call
+			return prev
+		})
+		host.Go(func() {
+			value, err := fn(ctx)
+			hook.update(func(prev asyncState[T]) asyncState[T] {
+				if prev.call != call {
+					return prev
+				}
+				return asyncState[T]{result: AsyncResult[T]{Value: value, Err: err}, call: call}
+			})
+		})
+		return cancel
+	}, dependencies)
+
+	return state.result
+}
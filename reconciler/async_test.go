@@ -0,0 +1,65 @@
+package reconciler
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/justjake/react4c/react"
+	"github.com/justjake/react4c/testdom"
+)
+
+type searchProps struct {
+	WithKey
+	Query string
+	// Each call waits for its query's channel, ignoring cancellation, like a
+	// request that finishes late.
+	Release   map[string]chan struct{}
+	Cancelled map[string]bool
+	Committed chan<- string
+}
+
+var search = FunctionComponent(func(props searchProps) AnyNode {
+	result := UseAsync(func(ctx context.Context) (string, error) {
+		<-props.Release[props.Query]
+		props.Cancelled[props.Query] = ctx.Err() != nil
+		return "results for " + props.Query, nil
+	}, props.Query)
+	UseEffect(func() {
+		if !result.Loading {
+			props.Committed <- result.Value
+		}
+	}, result.Value)
+	return Text(result.Value)
+})
+
+func TestUseAsyncDropsSupersededResults(t *testing.T) {
+	release := map[string]chan struct{}{"a": make(chan struct{}), "b": make(chan struct{})}
+	cancelled := map[string]bool{}
+	committed := make(chan string, 10)
+	props := func(query string) searchProps {
+		return searchProps{Query: query, Release: release, Cancelled: cancelled, Committed: committed}
+	}
+
+	container := testdom.NewElement("div")
+	root := NewTestDomRoot(container)
+	root.Render(search.Node(props("a")))
+	root.Render(search.Node(props("b")))
+	close(release["b"])
+	waitForCommit(t, committed, "results for b")
+
+	// a's call finishes after b's. Wait for it to return and deliver its
+	// result.
+	close(release["a"])
+	root.root.workers.Wait()
+	if got := innerText(container); got != "results for b" {
+		t.Errorf("rendered %q, want b's results", got)
+	}
+	root.Unmount()
+	close(committed)
+	for value := range committed {
+		t.Errorf("committed %q from a superseded call", value)
+	}
+	if !cancelled["a"] || cancelled["b"] {
+		t.Errorf("cancelled = %v, want only a's call cancelled", cancelled)
+	}
+}
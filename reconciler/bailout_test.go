@@ -0,0 +1,78 @@
+package reconciler
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/justjake/react4c/react"
+	"github.com/justjake/react4c/testdom"
+)
+
+type labelProps struct {
+	WithKey
+	WithRef[labelClass]
+	Label string
+}
+
+type labelClass struct {
+	ClassBase[labelProps, int]
+}
+
+func (c *labelClass) Render() AnyNode {
+	return Text(fmt.Sprintf("%s:%d", c.Props().Label, c.State()))
+}
+
+func (c *labelClass) ShouldComponentUpdate(next labelProps) bool {
+	return false
+}
+
+var labelComponent = Class(func(labelProps) *labelClass {
+	return &labelClass{}
+})
+
+func TestShouldComponentUpdateKeepsNewProps(t *testing.T) {
+	container := testdom.NewElement("div")
+	root := NewTestDomRoot(container)
+	ref := &RefStruct[*labelClass]{}
+	root.Render(labelComponent.Node(labelProps{WithRef: WithRef[labelClass]{Ref: ref}, Label: "a"}))
+	root.Render(labelComponent.Node(labelProps{WithRef: WithRef[labelClass]{Ref: ref}, Label: "b"}))
+	if got := innerText(container); got != "a:0" {
+		t.Errorf("after skipped update, rendered %q, want a:0", got)
+	}
+	ref.Current.SetState(1)
+	if got := innerText(container); got != "b:1" {
+		t.Errorf("after SetState, rendered %q, want b:1", got)
+	}
+	root.Unmount()
+}
+
+type memoLabelProps struct {
+	WithKey
+	Label   string
+	Ignored int
+}
+
+func TestMemoBailoutKeepsNewProps(t *testing.T) {
+	var setCount func(int)
+	inner := FunctionComponent(func(props memoLabelProps) AnyNode {
+		count, set := UseState(0)
+		setCount = set
+		return Text(fmt.Sprintf("%s:%d:%d", props.Label, props.Ignored, count))
+	})
+	memo := MemoFunc[memoLabelProps](inner, func(prev, next memoLabelProps) bool {
+		return prev.Label == next.Label
+	})
+
+	container := testdom.NewElement("div")
+	root := NewTestDomRoot(container)
+	root.Render(memo.Node(memoLabelProps{Label: "a", Ignored: 1}))
+	root.Render(memo.Node(memoLabelProps{Label: "a", Ignored: 2}))
+	if got := innerText(container); got != "a:1:0" {
+		t.Errorf("after memo bailout, rendered %q, want a:1:0", got)
+	}
+	setCount(1)
+	if got := innerText(container); got != "a:2:1" {
+		t.Errorf("after state update, rendered %q, want a:2:1", got)
+	}
+	root.Unmount()
+}
@@ -0,0 +1,89 @@
+package reconciler
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	. "github.com/justjake/react4c/react"
+	"github.com/justjake/react4c/testdom"
+)
+
+type trackerProps struct {
+	WithKey
+	ChildrenFunc[int]
+}
+
+func TestFuncChild(t *testing.T) {
+	var setPosition func(int)
+	tracker := FunctionComponent(func(props trackerProps) AnyNode {
+		position, set := UseState(1)
+		setPosition = set
+		return props.RenderChildren(position)
+	})
+
+	container := testdom.NewElement("div")
+	root := NewTestDomRoot(container)
+	defer root.Unmount()
+	root.Render(tracker.Node(trackerProps{}, FuncChild(func(position int) AnyNode {
+		return Text(fmt.Sprintf("at %d", position))
+	})))
+	if got := innerText(container); got != "at 1" {
+		t.Errorf("rendered %q, want at 1", got)
+	}
+	setPosition(2)
+	if got := innerText(container); got != "at 2" {
+		t.Errorf("after update, rendered %q, want at 2", got)
+	}
+
+	// A function child of the wrong type, or outside a component taking one,
+	// renders nothing and logs why.
+	logged := captureLog(func() {
+		root.Render(tracker.Node(trackerProps{}, FuncChild(func(label string) AnyNode {
+			return Text(label)
+		})))
+	})
+	if got := innerText(container); got != "" || !strings.Contains(logged, "can't set function child") {
+		t.Errorf("with a mistyped function child, rendered %q and logged %q", got, logged)
+	}
+	logged = captureLog(func() {
+		root.Render(Fragment(Text("x"), FuncChild(func(position int) AnyNode {
+			return Text("unused")
+		})))
+	})
+	if got := innerText(container); got != "x" || !strings.Contains(logged, "rendered outside of JSX") {
+		t.Errorf("with a stray function child, rendered %q and logged %q", got, logged)
+	}
+}
+
+func TestContextConsumer(t *testing.T) {
+	theme := CreateContext("light")
+	show := func(value string) AnyNode {
+		return Text(value)
+	}
+
+	container := testdom.NewElement("div")
+	root := NewTestDomRoot(container)
+	defer root.Unmount()
+	root.Render(theme.Consume(show))
+	if got := innerText(container); got != "light" {
+		t.Errorf("without a provider, rendered %q, want the default light", got)
+	}
+
+	tree := func(value string) AnyNode {
+		return theme.Provide(value,
+			theme.Consume(show),
+			theme.Consumer.Node(ConsumerProps[string]{}, FuncChild(func(value string) AnyNode {
+				return Text("/" + value)
+			})),
+		)
+	}
+	root.Render(tree("dark"))
+	if got := innerText(container); got != "dark/dark" {
+		t.Errorf("rendered %q, want dark/dark", got)
+	}
+	root.Render(tree("blue"))
+	if got := innerText(container); got != "blue/blue" {
+		t.Errorf("after the provided value changed, rendered %q, want blue/blue", got)
+	}
+}
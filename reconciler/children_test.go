@@ -0,0 +1,140 @@
+package reconciler
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/justjake/react4c/react"
+	"github.com/justjake/react4c/testdom"
+)
+
+type namedProps struct {
+	WithKey
+	Name string
+}
+
+// Renders its name and a count, which is kept as long as the component stays
+// mounted.
+func newCounter() (counter *FuncComponent[namedProps], set map[string]func(int)) {
+	set = make(map[string]func(int))
+	counter = FunctionComponent(func(props namedProps) AnyNode {
+		count, setCount := UseState(0)
+		set[props.Name] = setCount
+		return Text(fmt.Sprintf("%s%d;", props.Name, count))
+	})
+	return counter, set
+}
+
+func TestListKeepsSiblingsInPlace(t *testing.T) {
+	counter, set := newCounter()
+	node := func(items ...string) AnyNode {
+		return Fragment(
+			Map(items, func(item string, _ int) AnyNode {
+				return counter.Node(namedProps{Name: item})
+			}),
+			counter.Node(namedProps{Name: "footer"}),
+		)
+	}
+
+	container := testdom.NewElement("div")
+	root := NewTestDomRoot(container)
+	root.Render(node("a", "b"))
+	set["footer"](5)
+	set["a"](1)
+
+	steps := []struct {
+		items []string
+		want  string
+	}{
+		{[]string{"a"}, "a1;footer5;"},
+		{[]string{"a", "b", "c"}, "a1;b0;c0;footer5;"},
+		{nil, "footer5;"},
+	}
+	for _, step := range steps {
+		root.Render(node(step.items...))
+		if got := innerText(container); got != step.want {
+			t.Errorf("with items %v, rendered %q, want %q", step.items, got, step.want)
+		}
+	}
+	root.Unmount()
+}
+
+func TestListHasItsOwnKeySpace(t *testing.T) {
+	counter, set := newCounter()
+	node := func(items ...string) AnyNode {
+		return Fragment(
+			counter.Node(namedProps{WithKey: Key("x"), Name: "outer"}),
+			Map(items, func(item string, _ int) AnyNode {
+				return counter.Node(namedProps{WithKey: Key(item), Name: item})
+			}),
+		)
+	}
+
+	container := testdom.NewElement("div")
+	root := NewTestDomRoot(container)
+	root.Render(node("x", "y"))
+	set["outer"](1)
+	set["x"](2)
+	set["y"](3)
+	root.Render(node("y", "x"))
+	if got, want := innerText(container), "outer1;y3;x2;"; got != want {
+		t.Errorf("rendered %q, want %q", got, want)
+	}
+	root.Unmount()
+}
+
+func TestPlaceholderKeepsSiblingsInPlace(t *testing.T) {
+	counter, set := newCounter()
+	node := func(show bool) AnyNode {
+		return Fragment(
+			If(show, counter.Node(namedProps{Name: "maybe"})),
+			counter.Node(namedProps{Name: "always"}),
+		)
+	}
+
+	container := testdom.NewElement("div")
+	root := NewTestDomRoot(container)
+	root.Render(node(true))
+	set["always"](1)
+	set["maybe"](2)
+
+	root.Render(node(false))
+	if got, want := innerText(container), "always1;"; got != want {
+		t.Errorf("hidden, rendered %q, want %q", got, want)
+	}
+	// Shown again, it remounts before its sibling.
+	root.Render(node(true))
+	if got, want := innerText(container), "maybe0;always1;"; got != want {
+		t.Errorf("shown again, rendered %q, want %q", got, want)
+	}
+	root.Unmount()
+}
+
+func TestKeyedFragments(t *testing.T) {
+	counter, set := newCounter()
+	node := func(keys ...string) AnyNode {
+		return Fragment(Map(keys, func(key string, _ int) AnyNode {
+			return Fragment.Keyed(key,
+				counter.Node(namedProps{Name: key + "1"}),
+				counter.Node(namedProps{Name: key + "2"}),
+			)
+		}))
+	}
+
+	container := testdom.NewElement("div")
+	root := NewTestDomRoot(container)
+	root.Render(node("a", "b"))
+	set["a1"](1)
+	set["b2"](2)
+
+	root.Render(node("b", "a"))
+	if got, want := innerText(container), "b10;b22;a11;a20;"; got != want {
+		t.Errorf("reordered, rendered %q, want %q", got, want)
+	}
+	// A new key remounts the fragment's children.
+	root.Render(node("c", "a"))
+	if got, want := innerText(container), "c10;c20;a11;a20;"; got != want {
+		t.Errorf("replaced, rendered %q, want %q", got, want)
+	}
+	root.Unmount()
+}
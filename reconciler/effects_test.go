@@ -0,0 +1,118 @@
+package reconciler
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/justjake/react4c/react"
+	"github.com/justjake/react4c/testdom"
+)
+
+type phaseProps struct {
+	WithKey
+	WithChildren
+	Name  string
+	Count int
+	Log   func(string)
+}
+
+var phaseLogger = FunctionComponent(func(props phaseProps) AnyNode {
+	logPhase := func(phase string) func() func() {
+		return func() func() {
+			props.Log(fmt.Sprintf("%s %s %d", props.Name, phase, props.Count))
+			return func() { props.Log(fmt.Sprintf("%s %s cleanup %d", props.Name, phase, props.Count)) }
+		}
+	}
+	UseInsertionEffect(logPhase("insertion"), props.Count)
+	UseLayoutEffect(logPhase("layout"), props.Count)
+	UseEffect(logPhase("passive"), props.Count)
+	UseEffect(func() {
+		props.Log(props.Name + " once")
+	}, Once{})
+	// Each count adds a host node, placed when the commit mutates the host.
+	children := []AnyNode{}
+	for i := 0; i < props.Count; i++ {
+		children = append(children, Text(props.Name[:1]))
+	}
+	return Fragment(Fragment(children...), Fragment(props.Children...))
+})
+
+func TestEffectOrderOnUpdate(t *testing.T) {
+	container := testdom.NewElement("div")
+	var log []string
+	logFn := func(entry string) {
+		// Note what the host shows, to check when each phase runs.
+		log = append(log, entry+" sees "+innerText(container))
+	}
+	tree := func(count int) AnyNode {
+		return phaseLogger.Node(phaseProps{Name: "parent", Count: count, Log: logFn},
+			phaseLogger.Node(phaseProps{Name: "child", Count: count, Log: logFn}),
+		)
+	}
+	root := NewTestDomRoot(container)
+	defer root.Unmount()
+	root.Render(tree(1))
+	log = nil
+
+	root.Render(tree(2))
+	// Within each phase, all cleanups run before all effects, children before
+	// parents. Insertion effects run before the host changes, layout effects
+	// after, and effects with unchanged deps don't run.
+	want := []string{
+		"child insertion cleanup 1 sees pc",
+		"parent insertion cleanup 1 sees pc",
+		"child insertion 2 sees pc",
+		"parent insertion 2 sees pc",
+		"child layout cleanup 1 sees ppcc",
+		"parent layout cleanup 1 sees ppcc",
+		"child layout 2 sees ppcc",
+		"parent layout 2 sees ppcc",
+		"child passive cleanup 1 sees ppcc",
+		"parent passive cleanup 1 sees ppcc",
+		"child passive 2 sees ppcc",
+		"parent passive 2 sees ppcc",
+	}
+	if fmt.Sprint(log) != fmt.Sprint(want) {
+		t.Errorf("effects ran in order\n%q\nwant\n%q", log, want)
+	}
+}
+
+func TestAlwaysAndOnce(t *testing.T) {
+	var log []string
+	var setCount func(int)
+	counter := FunctionComponent(func(props WithKey) AnyNode {
+		count, set := UseState(0)
+		setCount = set
+		always := UseMemo(func() int { return count }, Always{})
+		once := UseMemo(func() int { return count }, Once{})
+		UseEffect(func() func() {
+			log = append(log, fmt.Sprintf("always %d", count))
+			return func() { log = append(log, fmt.Sprintf("always cleanup %d", count)) }
+		}, Always{})
+		UseEffect(func() func() {
+			log = append(log, fmt.Sprintf("once %d", count))
+			return func() { log = append(log, fmt.Sprintf("once cleanup %d", count)) }
+		}, Once{})
+		return Text(fmt.Sprintf("%d %d", always, once))
+	})
+
+	container := testdom.NewElement("div")
+	root := NewTestDomRoot(container)
+	root.Render(counter.Node(WithKey{}))
+	setCount(1)
+	setCount(2)
+	if got := innerText(container); got != "2 0" {
+		t.Errorf("rendered %q, want Always memo recomputed and Once memo kept", got)
+	}
+	root.Unmount()
+
+	want := []string{
+		"always 0", "once 0",
+		"always cleanup 0", "always 1",
+		"always cleanup 1", "always 2",
+		"always cleanup 2", "once cleanup 0",
+	}
+	if fmt.Sprint(log) != fmt.Sprint(want) {
+		t.Errorf("effects ran %q, want %q", log, want)
+	}
+}
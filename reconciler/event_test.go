@@ -0,0 +1,39 @@
+package reconciler
+
+import (
+	"testing"
+
+	. "github.com/justjake/react4c/react"
+	"github.com/justjake/react4c/testdom"
+)
+
+func TestUseEvent(t *testing.T) {
+	var setLabel func(string)
+	var handler func() string
+	stableRuns, closureRuns := 0, 0
+	button := FunctionComponent(func(props WithKey) AnyNode {
+		label, set := UseState("a")
+		setLabel = set
+		getLabel := func() string { return label }
+		handler = UseEvent(getLabel)
+		UseEffect(func() { stableRuns++ }, Deps(handler))
+		// A plain closure is new every render.
+		UseEffect(func() { closureRuns++ }, Deps(getLabel))
+		return Text(label)
+	})
+
+	root := NewTestDomRoot(testdom.NewElement("div"))
+	defer root.Unmount()
+	root.Render(button.Node(WithKey{}))
+	if got := handler(); got != "a" {
+		t.Errorf("handler() = %q, want a", got)
+	}
+	setLabel("b")
+	setLabel("c")
+	if got := handler(); got != "c" {
+		t.Errorf("handler() = %q after re-rendering, want the latest render's c", got)
+	}
+	if stableRuns != 1 || closureRuns != 3 {
+		t.Errorf("effects depending on the handler ran %d times, and on the closure %d times, want 1 and 3", stableRuns, closureRuns)
+	}
+}
@@ -0,0 +1,44 @@
+package reconciler
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	. "github.com/justjake/react4c/react"
+	"github.com/justjake/react4c/testdom"
+)
+
+func TestTornRenderEffectsSeeFinalSnapshot(t *testing.T) {
+	var version int64
+	subscribe := func(func()) func() { return func() {} }
+	var logged []string
+	reader := FunctionComponent(func(props WithKey) AnyNode {
+		v := UseSyncExternalStore(subscribe, func() int64 { return atomic.LoadInt64(&version) })
+		memo := UseMemo(func() int64 { return v }, Once{})
+		UseEffect(func() {
+			logged = append(logged, fmt.Sprintf("%d/%d", v, memo))
+		}, Once{})
+		return Text(fmt.Sprint(v))
+	})
+	changed := false
+	changer := FunctionComponent(func(props WithKey) AnyNode {
+		// Change the store after reader rendered, tearing its snapshot.
+		if !changed {
+			changed = true
+			atomic.AddInt64(&version, 1)
+		}
+		return nil
+	})
+
+	container := testdom.NewElement("div")
+	root := NewTestDomRoot(container)
+	root.Render(Fragment(reader.Node(WithKey{}), changer.Node(WithKey{})))
+	if got := innerText(container); got != "1" {
+		t.Errorf("rendered %q, want 1", got)
+	}
+	if fmt.Sprint(logged) != "[1/1]" {
+		t.Errorf("effect saw snapshot/memo %v, want [1/1]", logged)
+	}
+	root.Unmount()
+}
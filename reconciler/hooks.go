@@ -6,7 +6,9 @@ type fiberHooks struct {
 	allowMakeHook bool // false once mounted
 	nextHook      int
 	hooks         []react.HookInstance
-	cb            react.HookCallbacks
+	fiber         *fiber
+	// Set when the fiber's own state changes while it renders.
+	renderPhaseUpdate bool
 }
 
 func (h *fiberHooks) GetOrCreateHook(makeHook func() react.HookInstance) (instance react.HookInstance, found bool) {
@@ -26,5 +28,29 @@ func (h *fiberHooks) GetOrCreateHook(makeHook func() react.HookInstance) (instan
 }
 
 func (h *fiberHooks) HookCallbacks() react.HookCallbacks {
-	return h.cb
+	return h.fiber
+}
+
+func (h *fiberHooks) Identifier() string {
+	return h.fiber.identifier(h.nextHook)
+}
+
+func (h *fiberHooks) Go(fn func()) {
+	h.fiber.root.goWorker(fn)
+}
+
+func (h *fiberHooks) ReadContext(context any) (value any, ok bool) {
+	return h.fiber.readContext(context)
+}
+
+func (h *fiberHooks) Clock() react.Clock {
+	return h.fiber.root.clock
+}
+
+func (h *fiberHooks) Storage() react.StateStorage {
+	return h.fiber.root.storage
+}
+
+func (h *fiberHooks) PublicInstance() any {
+	return h.fiber.publicInstance
 }
@@ -2,6 +2,10 @@ package reconciler
 
 import (
 	"fmt"
+	"hash/fnv"
+	"reflect"
+	"strconv"
+	"strings"
 
 	. "github.com/justjake/react4c/react"
 	. "github.com/justjake/react4c/web"
@@ -23,15 +27,6 @@ func NewRenderer[
 	return &RenderAPI[Props, Comp, K]{config}
 }
 
-// Is this going to save keystrokes or cause pain?
-func (*RenderAPI[Props, Comp, K]) newSwitch() componentKindHandlers[Props, Comp] {
-	return componentKindHandlers[Props, Comp]{}
-}
-
-var globalState struct {
-	currentFiber *fiber
-}
-
 // Initially I started with a fiber-inspired design (React 16+), but it's quite
 // complicated and involved. Instead, we implement a more straightforward
 // stack-based resolver (React 15 and before).
@@ -61,21 +56,13 @@ type fiberTemp struct {
 	// Identifies this render generation
 	generation int
 
-	// The nearest ancestor that is actually mounted in some kinda DOM If we have
-	// a mounted node, it should be inserted into mountedParent's node by the end
-	// of the render.
-	mountedParent *fiber
-	// Index in the nearest parent DOM node, which may not be `fiber.parent.mounted`
-	startIndex int
-	// Most fibers are 1 element wide. Fragments are N elements wide, so this
-	// should be `N - 1` for fragments.
-	indexOffsetWidth int
 	// If true, fiber is known to be alive after this render
 	alive bool
 	// If true, fiber is known to need removal this render
 	dead bool
-	// If true, fiber is already inserted as intended. TODO: use for assertions
-	inserted bool
+	// If true, the fiber is new or moved, and its top-level host nodes need to
+	// be inserted into the nearest host parent during FlushChanges.
+	placement bool
 }
 
 // A fiber hosts an instance of a component instance across multiple renders. It
@@ -90,6 +77,9 @@ type fiber struct {
 	parent       *fiber
 	children     map[string]*fiber
 	deadChildren map[string]*fiber
+	childList    []*fiber // children in render order
+	index        int      // index in parent.childList
+	key          string   // key in parent.children
 
 	// TODO: separate attributes into "retained" between renders and "temporary"
 	// for current render only data.
@@ -97,111 +87,380 @@ type fiber struct {
 
 	// Rendering data.
 	// Retained across re-renders.
-	node     AnyNode // Component user resquested we render
-	rendered AnyNode // Subtree of that component
-	mounted  any     // associated renderer object
-	dirty    bool    // If true, this fiber should re-render during next render
+	node       AnyNode // Component user resquested we render
+	rendered   AnyNode // Subtree of that component
+	mounted    any     // associated renderer object
+	inserted   bool    // If true, mounted was inserted into its host parent
+	contexts   []any   // Contexts read by the latest render
+	dirty      bool    // If true, this fiber should re-render during next render
+	childDirty bool    // If true, some descendant is dirty
+	// Ref the instance is attached to, and the props that attached it. See
+	// attachRef.
+	ref       any
+	refSetter RefSetter
+	// Instance of a class component, kept across renders. Refs to the
+	// component point at it.
+	publicInstance any
 
 	// Hooks
-	// nextHook int
-	// hooks    []hook
+	hooks fiberHooks
 }
 
+// Find the fiber to render childNode. If the existing fiber at the same key
+// rendered a different component, it's retired to deadChildren and replaced
+// with a new fiber, so the new component mounts with fresh state. ok reports
+// if an existing fiber was re-used.
 func (f *fiber) findChild(index int, childNode AnyNode) (childFiber *fiber, ok bool) {
 	key := getKeyOrIndex(childNode, index)
 	if childFiber, ok := f.children[key]; ok {
-		if childFiber.node.component() != childNode.component() {
-			// Component changed, eg div -> span
-			return childFiber, false
-		} else {
+		if SameComponent(childFiber.node.GetComponent(), childNode.GetComponent()) {
 			return childFiber, true
 		}
+		// Component changed, eg div -> span
+		if f.deadChildren == nil {
+			f.deadChildren = make(map[string]*fiber)
+		}
+		f.deadChildren[key] = childFiber
 	}
-	childFiber = &fiber{
-		root:   f.root,
-		parent: f,
-	}
+	childFiber = newFiber(f.root, f, nil)
+	childFiber.key = key
 	if f.children == nil {
 		f.children = make(map[string]*fiber)
 	}
 	f.children[key] = childFiber
-	return childFiber, true
+	return childFiber, false
 }
 
+// Detach children that weren't rendered this pass, and queue them for
+// removal by Sweep once rendering is done.
 func (f *fiber) sweep() {
-	if f.children == nil {
-		return
+	for key, deadFiber := range f.deadChildren {
+		Logger.Printf("fiber.sweep(): remove replaced child %s [%s]", DisplayName(deadFiber.node.GetComponent()), key)
+		deadFiber.temp.dead = true
+		f.root.deletions = append(f.root.deletions, deadFiber)
+		delete(f.deadChildren, key)
 	}
 
 	for key, childFiber := range f.children {
 		if !childFiber.temp.alive {
-			Logger.Printf("fiber.sweep(): remove unused child %T [%d]", childFiber.node.component(), childFiber.temp.startIndex)
-			childFiber.unmount()
+			Logger.Printf("fiber.sweep(): remove unused child %s [%s]", DisplayName(childFiber.node.GetComponent()), key)
+			childFiber.temp.dead = true
+			f.root.deletions = append(f.root.deletions, childFiber)
 			delete(f.children, key)
+		} else {
+			childFiber.temp.alive = false
 		}
 	}
 }
 
-// TODO: schedule for re-render
-func (f *fiber) shouldRerender() {
-	if !f.dirty {
-		f.dirty = true
-		// TODO: schedule
+func newFiber(root *root, parent *fiber, node AnyNode) *fiber {
+	f := &fiber{
+		root:   root,
+		parent: parent,
+		node:   node,
 	}
+	f.hooks.fiber = f
+	f.hooks.allowMakeHook = true
+	return f
+}
+
+// Schedule the fiber to re-render. Safe to call from any goroutine. If called
+// while the fiber itself is rendering, the render is re-run right away
+// instead.
+func (f *fiber) ShouldRerender() {
+	if RenderingHookHost() == &f.hooks {
+		f.hooks.renderPhaseUpdate = true
+		return
+	}
+	f.root.scheduleUpdate(f)
+}
+
+// Mark the fiber dirty, and its ancestors as having a dirty descendant, so the
+// next render pass finds it. Must be called while holding root.renderMu.
+func (f *fiber) markDirty() {
+	f.dirty = true
+	for parent := f.parent; parent != nil && !parent.childDirty; parent = parent.parent {
+		parent.childDirty = true
+	}
+}
+
+// Nodes are the same if they're the same pointer. Parents that pass through a
+// node they received, like props.Children, let the child skip rendering.
+func sameNode(a AnyNode, b AnyNode) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	return va.Kind() == reflect.Pointer && va.Type() == vb.Type() && va.Pointer() == vb.Pointer()
 }
 
-// TODO
+// Unmount the subtree rooted at f in post-order, so children clean up before
+// their parents. Host nodes aren't removed here; only the top-level host nodes
+// of a removed subtree need to be removed from their parent, see Sweep.
 func (f *fiber) unmount() {
-	for _, hook := range f.hooks {
-		hook.unmount()
+	// Descendants may still be in root.rendered if a retried render dropped
+	// the subtree, so mark them all to skip their effects.
+	f.temp.dead = true
+	for _, child := range f.childList {
+		child.unmount()
 	}
+	for _, hook := range f.hooks.hooks {
+		if effect, ok := hook.(EffectHook); ok && effect.EffectPhase() == PassiveEffect {
+			// Passive cleanups wait for FlushEffects, after layout effects.
+			f.root.passiveUnmounts = append(f.root.passiveUnmounts, effect)
+			continue
+		}
+		hook.Unmount()
+	}
+	f.detachRef()
 	f.mounted = nil
 }
 
+// Point the ref in the fiber's props, if any, at the fiber's host node or class
+// instance. Refs are only set when they change, so commits don't touch refs of
+// fibers that didn't change.
+func (f *fiber) attachRef() {
+	instance := f.mounted
+	if instance == nil {
+		instance = f.publicInstance
+	}
+	if instance == nil {
+		return
+	}
+	setter, _ := f.node.GetProps().(RefSetter)
+	var target any
+	if setter != nil {
+		target = setter.RefTarget()
+	}
+	if sameRef(target, f.ref) {
+		return
+	}
+	f.detachRef()
+	if target != nil {
+		setter.SetRefInstance(instance)
+		f.ref, f.refSetter = target, setter
+	}
+}
+
+func (f *fiber) detachRef() {
+	if f.refSetter != nil {
+		f.refSetter.SetRefInstance(nil)
+		f.ref, f.refSetter = nil, nil
+	}
+}
+
+// Refs are usually pointers, compared by identity.
+func sameRef(a, b any) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if !va.IsValid() || !vb.IsValid() {
+		return va.IsValid() == vb.IsValid()
+	}
+	if va.Type() != vb.Type() {
+		return false
+	}
+	switch va.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return va.Pointer() == vb.Pointer()
+	}
+	return va.Type().Comparable() && a == b
+}
+
+// Limit on re-running a render because it updated its own state.
+const maxRenderPhaseUpdates = 25
+
+// Render the fiber's node with the fiber as the current hook host. The hook
+// host is scoped to the calling goroutine, so fibers of different roots can
+// render in parallel.
+//
+// If the component updates its own state while rendering, we throw away the
+// result and render again with the new state.
 func (f *fiber) invokeRenderWithHooks() AnyNode {
-	globalState.currentFiber = f
-	defer func() { globalState.currentFiber = nil }()
-	f.nextHook = 0
+	var result AnyNode
+	for attempt := 0; ; attempt++ {
+		f.hooks.nextHook = 0
+		f.hooks.renderPhaseUpdate = false
+		f.contexts = f.contexts[:0]
+
+		result = RenderWithHooks(&f.hooks, f.node.InvokeRender)
+		if f.hooks.nextHook < len(f.hooks.hooks) {
+			panic(fmt.Errorf("Re-render invoked %d hooks out of %d hooks", f.hooks.nextHook, len(f.hooks.hooks)))
+		}
 
-	result := f.node.invokeRender()
-	if f.nextHook < len(f.hooks) {
-		panic(fmt.Errorf("Re-render invoked %d hooks out of %d hooks", f.nextHook-1, len(f.hooks)))
+		if !f.hooks.renderPhaseUpdate {
+			break
+		}
+		if attempt >= maxRenderPhaseUpdates {
+			err := fmt.Errorf("Too many re-renders: %s updates its state every time it renders", f.componentChain())
+			if f.root.renderCaller {
+				panic(err)
+			}
+			// Keep the last result, rather than crash a goroutine that
+			// happened to flush an update, eg a worker.
+			Logger.Printf("fiber.invokeRenderWithHooks(): %v", err)
+			break
+		}
 	}
+	f.hooks.allowMakeHook = false
 
 	return result
 }
 
-type root struct {
-	fiber *fiber
-	host  any
+// Identifier for the hook at hookIndex, hashed from the keys on the path from
+// the root to f, so it only depends on the shape of the tree.
+func (f *fiber) identifier(hookIndex int) string {
+	hash := fnv.New64a()
+	for fiber := f; fiber != nil; fiber = fiber.parent {
+		hash.Write([]byte(fiber.key))
+		hash.Write([]byte{0})
+	}
+	fmt.Fprintf(hash, "%d", hookIndex)
+	return f.root.identifierPrefix + "r" + strconv.FormatUint(hash.Sum64(), 36)
 }
 
-func newRoot(host any, node AnyNode) *root {
-	root := root{
-		host: host,
+// Value of the nearest provider of context above f. Records that f reads
+// context, so f re-renders when the provided value changes.
+func (f *fiber) readContext(context any) (value any, ok bool) {
+	if !f.readsContext(context) {
+		f.contexts = append(f.contexts, context)
 	}
-	rootFiber := fiber{
-		root: &root,
-		node: node,
+	for p := f.parent; p != nil; p = p.parent {
+		if provider, ok := p.node.GetComponent().(ContextProviderComponent); ok && provider.ProvidedContext() == context {
+			return provider.ProvidedValue(p.node.GetProps()), true
+		}
 	}
-	root.fiber = &rootFiber
-	return &root
+	return nil, false
 }
 
-type componentKindHandlers[
-	Props HostProps,
-	Comp ComparableComponent[Props],
-] struct {
+func (f *fiber) readsContext(context any) bool {
+	for _, read := range f.contexts {
+		if read == context {
+			return true
+		}
+	}
+	return false
+}
+
+// Mark descendants of provider that read context dirty, so they re-render even
+// if a component between them bails out. Nested providers of the same context
+// shadow provider.
+func (provider *fiber) markContextReaders(context any) {
+	var visit func(f *fiber)
+	visit = func(f *fiber) {
+		for _, child := range f.childList {
+			if p, ok := child.node.GetComponent().(ContextProviderComponent); ok && p.ProvidedContext() == context {
+				continue
+			}
+			if child.readsContext(context) {
+				child.dirty = true
+				for p := child.parent; p != provider && !p.childDirty; p = p.parent {
+					p.childDirty = true
+				}
+			}
+			visit(child)
+		}
+	}
+	visit(provider)
+}
+
+// Describe the path from the root to f, eg "App > div > Counter", for errors.
+func (f *fiber) componentChain() string {
+	var names []string
+	for ancestor := f; ancestor != nil && ancestor.parent != nil; ancestor = ancestor.parent {
+		names = append(names, DisplayName(ancestor.node.GetComponent()))
+	}
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+	return strings.Join(names, " > ")
+}
+
+// Host operations the reconciler needs to place host nodes created by a
+// Renderer. This is the untyped subset of HostConfigMutationSupport used by
+// the stack reconciler; parents are either a host node or the root container.
+type hostMutations interface {
+	// Insert child into parent before beforeChild. Appends child if
+	// beforeChild is nil. child may already be in parent, in which case it
+	// should be moved.
+	InsertBefore(parent any, child any, beforeChild any)
+	RemoveChild(parent any, child any)
+}
+
+// Host node of the fiber's nearest host ancestor, or the root container.
+func (f *fiber) hostParent() any {
+	for parent := f.parent; parent != nil; parent = parent.parent {
+		if parent.mounted != nil {
+			return parent.mounted
+		}
+	}
+	return f.root.host
+}
+
+func (f *fiber) nextSibling() *fiber {
+	if f.parent == nil || f.index+1 >= len(f.parent.childList) {
+		return nil
+	}
+	return f.parent.childList[f.index+1]
+}
+
+// Find the host node that f's host nodes should be inserted before, by
+// walking forward through the tree until we find a host node that's already
+// in place. Fibers pending placement are skipped since they aren't in place
+// yet. We stop at the nearest host ancestor, since host nodes past it belong
+// to a different parent.
+//
+// Based on getHostSibling:
+// https://github.com/facebook/react/blob/2e0d86d22192ff0b13b71b4ad68fea46bf523ef6/packages/react-reconciler/src/ReactFiberCommitWork.new.js#L1588
+func (f *fiber) nextHostSibling() any {
+	node := f
+siblings:
+	for {
+		for node.nextSibling() == nil {
+			node = node.parent
+			if node == nil || node.mounted != nil {
+				return nil
+			}
+		}
+		node = node.nextSibling()
+		for node.mounted == nil {
+			// Not a host node; descend into it to find its first host node.
+			if node.temp.placement || len(node.childList) == 0 {
+				continue siblings
+			}
+			node = node.childList[0]
+		}
+		if !node.temp.placement {
+			return node.mounted
+		}
+	}
+}
+
+// Call fn with each top-level host node of f, in order. These are f's own host
+// node, or the first host nodes found in each of its children.
+func (f *fiber) eachHostNode(fn func(fiber *fiber)) {
+	if f.mounted != nil {
+		fn(f)
+		return
+	}
+	for _, child := range f.childList {
+		child.eachHostNode(fn)
+	}
+}
+
+type componentKindHandlers struct {
 	Nil      func()
 	Fragment func(FragmentProps)
 	Text     func(TextProps)
-	Host     func(Comp, Props)
+	HTML     func(HtmlTag, HTMLProps)
 	Other    func(AnyNode)
 }
 
-func (ops componentKindHandlers[Props, Comp]) Perform(node AnyNode) {
-	comp := node.component()
-	props := node.props()
+func (ops componentKindHandlers) Perform(node AnyNode) {
+	if IsEmpty(node) {
+		if ops.Nil != nil {
+			ops.Nil()
+		}
+		return
+	}
+
+	comp := node.GetComponent()
+	props := node.GetProps()
 
 	if ops.Nil != nil && comp == nil {
 		ops.Nil()
@@ -209,19 +468,19 @@ func (ops componentKindHandlers[Props, Comp]) Perform(node AnyNode) {
 	}
 
 	switch comp := comp.(type) {
-	case fragmentComponent:
+	case FragmentComponent:
 		if ops.Fragment != nil {
 			ops.Fragment(props.(FragmentProps))
 			return
 		}
-	case textComponent:
+	case TextComponent:
 		if ops.Text != nil {
 			ops.Text(props.(TextProps))
 			return
 		}
-	case Comp:
-		if ops.Host != nil {
-			ops.Host(comp, props.(Props))
+	case HtmlTag:
+		if ops.HTML != nil {
+			ops.HTML(comp, props.(HTMLProps))
 			return
 		}
 	}
@@ -244,7 +503,7 @@ func isPrimitiveComponent(node AnyNode) bool {
 }
 
 func getKeyOrIndex(node AnyNode, index int) string {
-	key := node.key()
+	key := node.GetKey()
 	if key != nil {
 		return fmt.Sprintf("key:%s", *key)
 	}
@@ -259,13 +518,17 @@ type Renderer func(fiber *fiber, nextRendered AnyNode)
 func removeDuplicateKeys(children []AnyNode) {
 	seen := make(map[string]bool)
 	for i, child := range children {
+		if IsEmpty(child) {
+			continue
+		}
 		key := getKeyOrIndex(child, i)
 		if seen[key] {
-			fmt.Printf("Key used more than once: %s", key)
-			if !child.clearKey() {
+			Logger.Printf("Key used more than once: %s", key)
+			if !child.ClearKey() {
 				panic(fmt.Errorf("Couldn't clear duplicate key: %s", key))
 			}
 		}
+		seen[key] = true
 	}
 }
 
@@ -283,23 +546,6 @@ func removeDuplicateKeys(children []AnyNode) {
 //      - On way back up:
 //      - For each fiber
 //        - If not alive, mark Dead
-func ReconcileAndMark(parentFiber *fiber, rendered AnyNode) {
-	fiber := parentFiber
-
-	if !isPrimitiveComponent(parentFiber.node) {
-		// We are rendering the contents of a custom component.
-		// Those contents need their own fiber.
-		childFiber, ok := parentFiber.findChild(0, rendered)
-	}
-
-	nextRendered := fiber.invokeRenderWithHooks()
-	if isPrimitiveComponent(fiber.node) {
-		renderer(fiber, nextRendered)
-	} else {
-		renderChildren(fiber, []AnyNode{nextRendered}, renderer)
-	}
-}
-
 // 2. Sweep dead fibers
 //    - Post-order depth first traversal: (on way back up)
 //    - Sweep all dead fibers
@@ -326,52 +572,198 @@ func ReconcileAndMark(parentFiber *fiber, rendered AnyNode) {
 //    - Run cleanup
 //    - Run next effect
 
-func Sweep(ancestor *fiber)              {}
-func FlushChanges(ancestor *fiber)       {}
-func FlushLayoutEffects(ancestor *fiber) {}
-func FlushPaint(ancestor *fiber)         {}
-func FlushEffects(ancestor *fiber)       {}
+// Unmount fibers removed during render. Each deleted fiber is the top of a
+// removed subtree, so only its top-level host nodes are removed from the host
+// parent; their descendants go along with them. A Fragment may have several
+// top-level host nodes.
+func Sweep(ancestor *fiber) {
+	root := ancestor.root
+	deletions := root.deletions
+	root.deletions = nil
+
+	for _, deleted := range deletions {
+		var hostNodes []any
+		deleted.eachHostNode(func(host *fiber) {
+			// Fibers created and dropped by renders of the same commit were
+			// never inserted.
+			if host.inserted {
+				hostNodes = append(hostNodes, host.mounted)
+			}
+		})
+		parent := deleted.hostParent()
+
+		deleted.unmount()
 
-func renderChildren(ancestor *fiber, children []AnyNode, renderer Renderer) {
-	ancestorIndexOffset := 0
-	if ancestor.mounted == nil {
-		ancestorIndexOffset = ancestor.temp.startIndex
+		if root.mutations != nil {
+			for _, hostNode := range hostNodes {
+				root.mutations.RemoveChild(parent, hostNode)
+			}
+		}
+	}
+}
+
+// Insert new and moved host nodes into their host parents, then attach refs of
+// fibers rendered this pass. Only fibers placed or rendered this pass are
+// visited, so the cost follows the size of the update, not of the tree.
+// Placements are in pre-order, so a fiber's host nodes are placed before its
+// descendants', and each placement lands before the next host sibling that's
+// already in place.
+func FlushChanges(ancestor *fiber) {
+	root := ancestor.root
+	placements, bailedOut := root.placements, root.bailedOut
+	root.placements, root.bailedOut = nil, nil
+	mutations := root.mutations
+	if mutations == nil {
+		return
+	}
+
+	for _, placed := range placements {
+		if placed.temp.dead || !placed.temp.placement {
+			continue
+		}
+		parent := placed.hostParent()
+		before := placed.nextHostSibling()
+		placed.temp.placement = false
+		placed.eachHostNode(func(host *fiber) {
+			mutations.InsertBefore(parent, host.mounted, before)
+			host.inserted = true
+			// Placing placed its top-level host nodes too.
+			for f := host; f != placed; f = f.parent {
+				f.temp.placement = false
+			}
+		})
+	}
+
+	for _, fibers := range [][]*fiber{root.rendered, bailedOut} {
+		for _, f := range fibers {
+			if !f.temp.dead {
+				f.attachRef()
+			}
+		}
 	}
-	widthOffset := 0
+}
+
+// Run insertion effects of fibers rendered this pass, before host mutations.
+func FlushInsertionEffects(ancestor *fiber) {
+	flushEffectPhase(ancestor.root.rendered, InsertionEffect)
+}
+
+// Run layout effects of fibers rendered this pass, after host mutations.
+func FlushLayoutEffects(ancestor *fiber) {
+	flushEffectPhase(ancestor.root.rendered, LayoutEffect)
+}
+
+func FlushPaint(ancestor *fiber) {}
+
+// Run passive effect cleanups of unmounted fibers, then passive effects of
+// fibers rendered this pass. This is the last step of a commit.
+func FlushEffects(ancestor *fiber) {
+	root := ancestor.root
+	unmounts := root.passiveUnmounts
+	root.passiveUnmounts = nil
+	for _, effect := range unmounts {
+		effect.Unmount()
+	}
+
+	flushEffectPhase(root.rendered, PassiveEffect)
+	root.rendered = nil
+}
+
+// Run all cleanups for the phase, then all effects. fibers are in post-order,
+// so children's effects run before their parents'.
+func flushEffectPhase(fibers []*fiber, phase EffectPhase) {
+	eachEffect := func(fn func(EffectHook)) {
+		for _, f := range fibers {
+			if f.temp.dead {
+				continue
+			}
+			for _, hook := range f.hooks.hooks {
+				if effect, ok := hook.(EffectHook); ok && effect.EffectPhase() == phase {
+					fn(effect)
+				}
+			}
+		}
+	}
+	eachEffect(EffectHook.CleanupEffect)
+	eachEffect(EffectHook.RunEffect)
+}
+
+func renderChildren(ancestor *fiber, children []AnyNode, renderer Renderer) {
+	removeDuplicateKeys(children)
+
+	childList := make([]*fiber, 0, len(children))
+	// Index in the previous childList of the last child that stayed in place.
+	// Reused children before it have moved, and need placement.
+	// https://github.com/facebook/react/blob/2e0d86d22192ff0b13b71b4ad68fea46bf523ef6/packages/react-reconciler/src/ReactChildFiber.new.js#L331
+	lastPlacedIndex := 0
 	// TODO: this is basically our reconciler algo...
 	for i, childNode := range children {
-		childFiber, _ := ancestor.findChild(i, childNode)
-		comp := childNode.component()
+		if IsEmpty(childNode) {
+			// Placeholder renders nothing, but keeps its index so siblings
+			// keep their keys. Any fiber previously in this slot is swept.
+			continue
+		}
+		childFiber, reused := ancestor.findChild(i, childNode)
+		if !reused || childFiber.index < lastPlacedIndex {
+			childFiber.temp.placement = true
+			ancestor.root.placements = append(ancestor.root.placements, childFiber)
+		} else {
+			lastPlacedIndex = childFiber.index
+		}
+		childFiber.index = len(childList)
+		childList = append(childList, childFiber)
+
+		comp := childNode.GetComponent()
 		prevNode := childFiber.node
 		childFiber.temp.alive = true // Mark
 
-		if memo, ok := comp.(MemoComponent); ok && memo.SameComponent(prevNode.component()) {
-			childFiber.dirty = childFiber.dirty || memo.PropsEqual(prevNode.props(), childNode.props())
+		if prevNode != nil && sameNode(prevNode, childNode) {
+			// Same node as last render, so only re-render if the child's own
+			// state changed.
+		} else if memo, ok := comp.(MemoComponent); ok && prevNode != nil && memo.SameComponent(prevNode.GetComponent()) {
+			childFiber.dirty = childFiber.dirty || !memo.PropsEqual(prevNode.GetProps(), childNode.GetProps())
+		} else if class, ok := comp.(ClassComponent); ok && childFiber.publicInstance != nil {
+			childFiber.dirty = childFiber.dirty || class.ShouldUpdate(childFiber.publicInstance, childNode.GetProps())
 		} else {
 			childFiber.dirty = true
 		}
 
 		if childFiber.dirty {
-			childFiber.node = childNode
+			if provider, ok := comp.(ContextProviderComponent); ok && prevNode != nil && provider.ValueChanged(prevNode.GetProps(), childNode.GetProps()) {
+				childFiber.markContextReaders(provider.ProvidedContext())
+			}
+		}
+		// Keep the latest props even if the child bails out, so its next state
+		// update renders with them, like React.
+		childFiber.node = childNode
+		if !childFiber.dirty && prevNode != nil && !sameNode(prevNode, childNode) {
+			// Its ref may still have changed.
+			ancestor.root.bailedOut = append(ancestor.root.bailedOut, childFiber)
 		}
 
-		childFiber.temp.startIndex = i + widthOffset + ancestorIndexOffset
 		render(childFiber, renderer)
-		widthOffset += childFiber.temp.indexOffsetWidth
 	}
+	ancestor.childList = childList
 
 	// Unmount fibers no longer retained after this render
 	ancestor.sweep() // Sweep
-	if ancestor.mounted == nil {
-		ancestor.temp.indexOffsetWidth = widthOffset
-		Logger.Printf("renderChildren: %T with indexOffsetWidth %d", ancestor.node.component(), ancestorIndexOffset)
-	}
 }
 
 func render(fiber *fiber, renderer Renderer) {
-	if fiber.mounted != nil && !fiber.dirty {
+	// Fibers that rendered before only re-render when dirty. Otherwise we bail
+	// out and keep the previous render, but still visit children if one of
+	// them is dirty.
+	if !fiber.hooks.allowMakeHook && !fiber.dirty {
+		if fiber.childDirty {
+			fiber.childDirty = false
+			for _, child := range fiber.childList {
+				render(child, renderer)
+			}
+		}
 		return
 	}
+	fiber.childDirty = false
+
 	// This I'm always confused by.
 	// If our custom component returned nil, what do?
 	// Do we need to spawn another fiber depending on the child type?
@@ -383,10 +775,17 @@ func render(fiber *fiber, renderer Renderer) {
 	// TODO: support custom components
 	// prevRendered := fiber.rendered
 
+	if class, ok := fiber.node.GetComponent().(ClassComponent); ok && fiber.publicInstance == nil {
+		fiber.publicInstance = class.NewInstance(fiber.node.GetProps())
+	}
 	nextRendered := fiber.invokeRenderWithHooks()
 	if isPrimitiveComponent(fiber.node) {
 		renderer(fiber, nextRendered)
 	} else {
 		renderChildren(fiber, []AnyNode{nextRendered}, renderer)
 	}
+	fiber.rendered = nextRendered
+	fiber.dirty = false
+	// After children, so effects run in post-order.
+	fiber.root.rendered = append(fiber.root.rendered, fiber)
 }
@@ -0,0 +1,142 @@
+package reconciler
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/justjake/react4c/react"
+	"github.com/justjake/react4c/testdom"
+)
+
+// Log output written while running fn.
+func captureLog(fn func()) string {
+	var buf bytes.Buffer
+	var mu sync.Mutex
+	Logger.SetOutput(writerFunc(func(p []byte) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return buf.Write(p)
+	}))
+	defer Logger.SetOutput(discard{})
+	fn()
+	mu.Lock()
+	defer mu.Unlock()
+	return buf.String()
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (fn writerFunc) Write(p []byte) (int, error) { return fn(p) }
+
+// Run fn on another goroutine, returning what it panicked with, if anything.
+func fromWorker(fn func()) (recovered any) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() { recovered = recover() }()
+		fn()
+	}()
+	<-done
+	return recovered
+}
+
+func catchPanic(fn func()) (recovered any) {
+	defer func() { recovered = recover() }()
+	fn()
+	return nil
+}
+
+type looperProps struct {
+	WithKey
+	Loop bool
+}
+
+func TestNestedUpdateLimit(t *testing.T) {
+	var setLooping func(bool)
+	looper := FunctionComponent(func(props looperProps) AnyNode {
+		looping, setLoopingState := UseState(false)
+		count, setCount := UseState(0)
+		setLooping = setLoopingState
+		UseEffect(func() {
+			if looping || props.Loop {
+				setCount(count + 1)
+			}
+		}, Always{})
+		return Text("looper")
+	})
+
+	container := testdom.NewElement("div")
+	root := NewTestDomRoot(container)
+	root.Render(looper.Node(looperProps{}))
+
+	var recovered any
+	log := captureLog(func() {
+		recovered = fromWorker(func() { setLooping(true) })
+	})
+	if recovered != nil {
+		t.Fatalf("update from a worker panicked: %v", recovered)
+	}
+	if !strings.Contains(log, "Maximum update depth exceeded") {
+		t.Errorf("expected the dropped updates to be logged, got %q", log)
+	}
+
+	// The root keeps working, and the caller of Render sees the error.
+	recovered = catchPanic(func() { root.Render(looper.Node(looperProps{WithKey: WithKey{Key: Some("remount")}})) })
+	if recovered != nil {
+		t.Errorf("remount panicked: %v", recovered)
+	}
+	recovered = catchPanic(func() {
+		root.Render(looper.Node(looperProps{Loop: true}))
+	})
+	if err, ok := recovered.(error); !ok || !strings.Contains(err.Error(), "Maximum update depth exceeded") {
+		t.Errorf("Render recovered %v, want maximum update depth error", recovered)
+	}
+}
+
+func TestRenderPhaseUpdateLimit(t *testing.T) {
+	var setLooping func(bool)
+	looper := FunctionComponent(func(props WithKey) AnyNode {
+		looping, setLoopingState := UseState(false)
+		count, setCount := UseState(0)
+		setLooping = setLoopingState
+		if looping {
+			setCount(count + 1)
+		}
+		return Text("looper")
+	})
+
+	container := testdom.NewElement("div")
+	root := NewTestDomRoot(container)
+	root.Render(looper.Node(WithKey{}))
+
+	var recovered any
+	log := captureLog(func() {
+		recovered = fromWorker(func() { setLooping(true) })
+	})
+	if recovered != nil {
+		t.Fatalf("update from a worker panicked: %v", recovered)
+	}
+	if !strings.Contains(log, "Too many re-renders") {
+		t.Errorf("expected the re-renders to be logged, got %q", log)
+	}
+
+	recovered = catchPanic(func() {
+		RenderToString(looper.Node(WithKey{}))
+	})
+	if recovered != nil {
+		t.Errorf("RenderToString of a stable component panicked: %v", recovered)
+	}
+	always := FunctionComponent(func(props WithKey) AnyNode {
+		count, setCount := UseState(0)
+		setCount(count + 1)
+		return Text("always")
+	})
+	recovered = catchPanic(func() {
+		RenderToString(always.Node(WithKey{}))
+	})
+	if err, ok := recovered.(error); !ok || !strings.Contains(err.Error(), "Too many re-renders") {
+		t.Errorf("RenderToString recovered %v, want too many re-renders error", recovered)
+	}
+}
@@ -0,0 +1,60 @@
+package reconciler
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/justjake/react4c/react"
+	"github.com/justjake/react4c/testdom"
+)
+
+type noteProps struct {
+	WithKey
+	Name string
+}
+
+func TestPersistentStateKeyChange(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	storage := NewMemoryStorage()
+	var setNote func(string)
+	note := FunctionComponent(func(props noteProps) AnyNode {
+		value, set := UsePersistentState(props.Name, "empty")
+		setNote = set
+		return Text(value)
+	})
+
+	container := testdom.NewElement("div")
+	root := NewTestDomRoot(container, WithClock(clock), WithStateStorage(storage))
+	root.Render(note.Node(noteProps{Name: "a"}))
+	setNote("x")
+	if got := innerText(container); got != "x" {
+		t.Errorf("after set, rendered %q, want x", got)
+	}
+
+	// Change the key before the write is due.
+	root.Render(note.Node(noteProps{Name: "b"}))
+	if got := innerText(container); got != "empty" {
+		t.Errorf("after key change, rendered %q, want empty", got)
+	}
+
+	// The pending value is written to its own key.
+	deadline := time.Now().Add(time.Second)
+	for {
+		if data, _ := storage.Get("a"); string(data) == `"x"` {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal(`"x" was never written to key a`)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if data, ok := storage.Get("b"); ok {
+		t.Errorf("key b = %s, want nothing written", data)
+	}
+
+	root.Render(note.Node(noteProps{Name: "a"}))
+	if got := innerText(container); got != "x" {
+		t.Errorf("back at key a, rendered %q, want x", got)
+	}
+	root.Unmount()
+}
@@ -0,0 +1,124 @@
+package reconciler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/justjake/react4c/react"
+	"github.com/justjake/react4c/testdom"
+)
+
+type sourceProps struct {
+	WithKey
+	Source    string
+	Fetches   *int64
+	Committed chan<- string
+}
+
+var querySource = FunctionComponent(func(props sourceProps) AnyNode {
+	result := UseQuery("source", func(ctx context.Context) (string, error) {
+		n := atomic.AddInt64(props.Fetches, 1)
+		return fmt.Sprintf("%s%d", props.Source, n), nil
+	}, QueryOptions{StaleTime: time.Hour})
+	UseEffect(func() {
+		props.Committed <- result.Value
+	}, result.Value)
+	return Text(result.Value)
+})
+
+// Wait until a value committed to committed is want.
+func waitForCommit(t *testing.T, committed <-chan string, want string) {
+	t.Helper()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case got := <-committed:
+			if got == want {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("%q was never committed", want)
+		}
+	}
+}
+
+func TestQueryRefetchAfterObserverUnmounts(t *testing.T) {
+	cache := NewQueryCache()
+	var fetchesA, fetchesB int64
+	render := func(source string, fetches *int64, committed chan<- string) *Root {
+		root := NewTestDomRoot(testdom.NewElement("div"))
+		root.Render(QueryCacheProvider(cache, querySource.Node(sourceProps{Source: source, Fetches: fetches, Committed: committed})))
+		return root
+	}
+
+	committedB := make(chan string, 100)
+	rootB := render("b", &fetchesB, committedB)
+	waitForCommit(t, committedB, "b1")
+	// Mounts with the fresh result, so it doesn't fetch.
+	rootA := render("a", &fetchesA, make(chan string, 100))
+	rootA.Unmount()
+
+	// Refetching must use the component still mounted on rootB, not the one
+	// on the unmounted rootA.
+	cache.Invalidate("source")
+	waitForCommit(t, committedB, "b2")
+	if n := atomic.LoadInt64(&fetchesA); n != 0 {
+		t.Errorf("unmounted component fetched %d times", n)
+	}
+	rootB.Unmount()
+}
+
+func TestQueryStaleTimeUsesRootClock(t *testing.T) {
+	cache := NewQueryCache()
+	clock := NewFakeClock(time.Unix(0, 0))
+	var fetches int64
+	render := func(source string) (*Root, chan string) {
+		committed := make(chan string, 100)
+		root := NewTestDomRoot(testdom.NewElement("div"), WithClock(clock))
+		root.Render(QueryCacheProvider(cache, querySource.Node(sourceProps{Source: source, Fetches: &fetches, Committed: committed})))
+		return root, committed
+	}
+
+	rootA, committed := render("a")
+	waitForCommit(t, committed, "a1")
+	defer rootA.Unmount()
+
+	// Still fresh, so mounting doesn't fetch.
+	clock.Advance(59 * time.Minute)
+	rootB, committed := render("b")
+	waitForCommit(t, committed, "a1")
+	rootB.Unmount()
+	if n := atomic.LoadInt64(&fetches); n != 1 {
+		t.Errorf("fetched %d times while fresh, want 1", n)
+	}
+
+	// Past StaleTime, mounting fetches again.
+	clock.Advance(2 * time.Minute)
+	rootC, committed := render("c")
+	waitForCommit(t, committed, "c2")
+	rootC.Unmount()
+}
+
+func TestQueryRenderToString(t *testing.T) {
+	cache := NewQueryCache()
+	page := QueryCacheProvider(cache, querySource.Node(sourceProps{Source: "unused", Committed: make(chan string, 1)}))
+
+	err := PrefetchQuery(context.Background(), cache, "source", func(ctx context.Context) (string, error) {
+		return "prefetched", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if html := RenderToString(page); !strings.Contains(html, "prefetched") {
+		t.Errorf("after PrefetchQuery, rendered %q, want the prefetched data", html)
+	}
+
+	cache.Set("source", "set")
+	if html := RenderToString(page); !strings.Contains(html, "set") {
+		t.Errorf("after Set, rendered %q, want the set data", html)
+	}
+}
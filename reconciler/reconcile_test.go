@@ -0,0 +1,123 @@
+package reconciler
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/justjake/react4c/react"
+	"github.com/justjake/react4c/testdom"
+	. "github.com/justjake/react4c/web"
+)
+
+type itemProps struct {
+	WithKey
+	Name string
+}
+
+type groupProps struct {
+	WithKey
+	WithChildren
+}
+
+// Divs under parent by their text, to check they're moved rather than
+// recreated.
+func divsByText(parent *testdom.Element) map[string]*testdom.Element {
+	divs := make(map[string]*testdom.Element)
+	for _, child := range parent.Children {
+		if el, ok := child.(*testdom.Element); ok {
+			divs[innerText(el)] = el
+		}
+	}
+	return divs
+}
+
+func TestKeyedReorder(t *testing.T) {
+	mounts := 0
+	item := FunctionComponent(func(props itemProps) AnyNode {
+		id := UseMemo(func() int {
+			mounts++
+			return mounts
+		}, Once{})
+		// Several host nodes per item.
+		return Fragment(Div.Node(HTMLProps{}, Text(fmt.Sprintf("%s%d", props.Name, id))), Text(";"))
+	})
+	group := FunctionComponent(func(props groupProps) AnyNode {
+		return Fragment(props.Children...)
+	})
+	node := func(groupFirst bool, names ...string) AnyNode {
+		items := make([]AnyNode, len(names))
+		for i, name := range names {
+			items[i] = item.Node(itemProps{WithKey: Key(name), Name: name})
+		}
+		g := group.Node(groupProps{WithKey: Key("group")}, Fragment.Keyed("inner", items...))
+		last := item.Node(itemProps{WithKey: Key("z"), Name: "z"})
+		if groupFirst {
+			return Fragment(g, last)
+		}
+		return Fragment(last, g)
+	}
+
+	container := testdom.NewElement("div")
+	root := NewTestDomRoot(container)
+	root.Render(node(true, "a", "b", "c"))
+	if got := innerText(container); got != "a1;b2;c3;z4;" {
+		t.Fatalf("rendered %q, want a1;b2;c3;z4;", got)
+	}
+	before := divsByText(container)
+
+	steps := []struct {
+		groupFirst bool
+		names      []string
+		want       string
+	}{
+		{true, []string{"c", "a", "b"}, "c3;a1;b2;z4;"},
+		{false, []string{"b", "c", "a"}, "z4;b2;c3;a1;"},
+		{false, []string{"a", "c"}, "z4;a1;c3;"},
+		{true, []string{"c", "d", "a"}, "c3;d5;a1;z4;"},
+	}
+	for _, step := range steps {
+		root.Render(node(step.groupFirst, step.names...))
+		if got := innerText(container); got != step.want {
+			t.Errorf("after rendering %v, rendered %q, want %q", step.names, got, step.want)
+		}
+		for text, div := range divsByText(container) {
+			if prev, ok := before[text]; ok && prev != div {
+				t.Errorf("after rendering %v, %s was recreated instead of moved", step.names, text)
+			}
+		}
+	}
+	root.Unmount()
+}
+
+// Comparable, but == panics when render holds a func.
+type dynamicComponent struct {
+	render any
+}
+
+func (c dynamicComponent) Render(props WithKey) AnyNode {
+	return c.render.(func() AnyNode)()
+}
+
+func TestSwitchingComponentsRemounts(t *testing.T) {
+	mounts := 0
+	mounted := func(label string) func() AnyNode {
+		return func() AnyNode {
+			UseEffect(func() { mounts++ }, Once{})
+			return Text(label)
+		}
+	}
+	first, second := dynamicComponent{mounted("first")}, dynamicComponent{mounted("second")}
+
+	container := testdom.NewElement("div")
+	root := NewTestDomRoot(container)
+	defer root.Unmount()
+	root.Render(JSX[WithKey](first, WithKey{}))
+	root.Render(JSX[WithKey](first, WithKey{}))
+	if mounts != 1 {
+		t.Errorf("mounted %d times re-rendering the same component, want 1", mounts)
+	}
+	root.Render(JSX[WithKey](second, WithKey{}))
+	if got := innerText(container); got != "second" || mounts != 2 {
+		t.Errorf("after switching components, rendered %q and mounted %d times, want second mounted twice", got, mounts)
+	}
+}
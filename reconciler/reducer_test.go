@@ -0,0 +1,90 @@
+package reconciler
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/justjake/react4c/react"
+	"github.com/justjake/react4c/testdom"
+)
+
+type logProps struct {
+	WithKey
+	OnMount []string
+}
+
+func appendAction(state string, action string) string {
+	if action == "noop" {
+		return state
+	}
+	return state + action
+}
+
+func TestUseReducerOrder(t *testing.T) {
+	renders := 0
+	var dispatch func(string)
+	log := FunctionComponent(func(props logProps) AnyNode {
+		renders++
+		state, d := UseReducer(appendAction, "")
+		dispatch = d
+		UseLayoutEffect(func() {
+			// Dispatched while committing, so they wait for one render.
+			for _, action := range props.OnMount {
+				dispatch(action)
+			}
+		}, Once{})
+		return Text(state)
+	})
+
+	container := testdom.NewElement("div")
+	root := NewTestDomRoot(container)
+	defer root.Unmount()
+	root.Render(log.Node(logProps{OnMount: []string{"a", "b", "c"}}))
+	if got := innerText(container); got != "abc" {
+		t.Errorf("rendered %q, want actions applied in order", got)
+	}
+	if renders != 2 {
+		t.Errorf("rendered %d times, want 2: one for the mount, one for the queued actions", renders)
+	}
+
+	var wg sync.WaitGroup
+	d := dispatch
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d("x")
+		}()
+	}
+	wg.Wait()
+	// Wait for a render another goroutine may still be committing.
+	root.Render(log.Node(logProps{}))
+	if got := innerText(container); got != "abc"+strings.Repeat("x", 20) {
+		t.Errorf("after concurrent dispatches, rendered %q, want every action applied", got)
+	}
+}
+
+func TestUseReducerEagerBailout(t *testing.T) {
+	renders := 0
+	var dispatch func(string)
+	log := FunctionComponent(func(props logProps) AnyNode {
+		renders++
+		state, d := UseReducer(appendAction, "")
+		dispatch = d
+		return Text(state)
+	})
+
+	container := testdom.NewElement("div")
+	root := NewTestDomRoot(container)
+	defer root.Unmount()
+	root.Render(log.Node(logProps{}))
+	dispatch("noop")
+	if renders != 1 {
+		t.Errorf("rendered %d times after an action that changes nothing, want 1", renders)
+	}
+	dispatch("a")
+	if got := innerText(container); got != "a" || renders != 2 {
+		t.Errorf("after an action, rendered %q %d times, want a twice", got, renders)
+	}
+}
@@ -0,0 +1,82 @@
+package reconciler
+
+import (
+	"testing"
+
+	. "github.com/justjake/react4c/react"
+	"github.com/justjake/react4c/testdom"
+)
+
+type countingRef struct {
+	current *refClass
+	sets    int
+}
+
+func (r *countingRef) Set(instance *refClass) {
+	r.current = instance
+	r.sets++
+}
+
+type refProps struct {
+	WithKey
+	WithRef[refClass]
+	Skip bool
+}
+
+type refClass struct {
+	ClassBase[refProps, int]
+}
+
+func (c *refClass) Render() AnyNode {
+	return Text("instance")
+}
+
+func (c *refClass) ShouldComponentUpdate(next refProps) bool {
+	return !next.Skip
+}
+
+var refComponent = Class(func(refProps) *refClass {
+	return &refClass{}
+})
+
+func TestRefsOnlySetWhenChanged(t *testing.T) {
+	var setCount func(int)
+	sibling := FunctionComponent(func(props WithKey) AnyNode {
+		_, set := UseState(0)
+		setCount = set
+		return Text("sibling")
+	})
+	node := func(ref *countingRef, skip bool) AnyNode {
+		return Fragment(
+			refComponent.Node(refProps{WithRef: WithRef[refClass]{Ref: ref}, Skip: skip}),
+			sibling.Node(WithKey{}),
+		)
+	}
+
+	first := &countingRef{}
+	root := NewTestDomRoot(testdom.NewElement("div"))
+	root.Render(node(first, false))
+	if first.current == nil || first.sets != 1 {
+		t.Fatalf("after mount, ref set %d times to %v, want once to the instance", first.sets, first.current)
+	}
+	instance := first.current
+
+	setCount(1)
+	root.Render(node(first, false))
+	if first.sets != 1 {
+		t.Errorf("ref set %d times without changing, want 1", first.sets)
+	}
+
+	// Changing the ref detaches the old one, even if the component skips the
+	// render.
+	second := &countingRef{}
+	root.Render(node(second, true))
+	if first.current != nil || second.current != instance {
+		t.Errorf("after changing refs, old = %v, new = %v, want nil and the instance", first.current, second.current)
+	}
+
+	root.Unmount()
+	if second.current != nil {
+		t.Errorf("after unmount, ref = %v, want nil", second.current)
+	}
+}
@@ -0,0 +1,49 @@
+package reconciler
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/justjake/react4c/react"
+	"github.com/justjake/react4c/testdom"
+)
+
+func TestRenderPhaseUpdateKeepsLatestHooks(t *testing.T) {
+	var logged []int
+	var memoized []int
+	component := FunctionComponent(func(props WithKey) AnyNode {
+		count, set := UseState(0)
+		if count < 2 {
+			set(count + 1)
+		}
+		memo := UseMemo(func() int { return count }, Once{})
+		memoized = append(memoized, memo)
+		UseEffect(func() {
+			logged = append(logged, count)
+		}, Once{})
+		return Text(fmt.Sprint(count))
+	})
+
+	container := testdom.NewElement("div")
+	root := NewTestDomRoot(container)
+	root.Render(component.Node(WithKey{}))
+	if got := innerText(container); got != "2" {
+		t.Errorf("rendered %q, want 2", got)
+	}
+	if fmt.Sprint(logged) != "[2]" {
+		t.Errorf("effect saw %v, want [2]", logged)
+	}
+	if last := memoized[len(memoized)-1]; last != 2 {
+		t.Errorf("UseMemo returned %d from a thrown away render, want 2", last)
+	}
+
+	// Committed deps are compared on the next render.
+	root.Render(component.Node(WithKey{}))
+	if fmt.Sprint(logged) != "[2]" {
+		t.Errorf("after re-render, effect ran with %v, want [2]", logged)
+	}
+	if last := memoized[len(memoized)-1]; last != 2 {
+		t.Errorf("after re-render, UseMemo returned %d, want 2", last)
+	}
+	root.Unmount()
+}
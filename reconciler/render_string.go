@@ -0,0 +1,40 @@
+package reconciler
+
+import (
+	"strings"
+
+	. "github.com/justjake/react4c/react"
+	. "github.com/justjake/react4c/web"
+)
+
+// RenderToString renders node to HTML. Each call renders an independent root,
+// so it's safe to call from many goroutines at once, eg from HTTP handlers.
+func RenderToString(node AnyNode, options ...RootOption) string {
+	var builder strings.Builder
+	var renderer Renderer
+	renderer = func(fiber *fiber, nextNode AnyNode) {
+		componentKindHandlers{
+			Nil: func() {},
+			Fragment: func(props FragmentProps) {
+				renderChildren(fiber, props.Children, renderer)
+			},
+			Text: func(props TextProps) {
+				builder.WriteString(props.Text)
+			},
+			HTML: func(tag HtmlTag, props HTMLProps) {
+				if tag.SelfClose && len(props.Children) == 0 {
+					builder.WriteString(tag.SelfCloseTag(props))
+				} else {
+					builder.WriteString(tag.OpenTag(props))
+					renderChildren(fiber, props.Children, renderer)
+					builder.WriteString(tag.CloseTag())
+				}
+			},
+			Other: func(node AnyNode) {
+				renderChildren(fiber, []AnyNode{node}, renderer)
+			},
+		}.Perform(nextNode)
+	}
+	newRoot(&builder, renderer, options...).renderStatic(node)
+	return builder.String()
+}
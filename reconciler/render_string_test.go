@@ -0,0 +1,127 @@
+package reconciler
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/justjake/react4c/react"
+	"github.com/justjake/react4c/testdom"
+	. "github.com/justjake/react4c/web"
+)
+
+type rowProps struct {
+	WithKey
+	Label string
+}
+
+var row = FunctionComponent(func(props rowProps) AnyNode {
+	count, _ := UseState(len(props.Label))
+	label := UseMemo(func() string {
+		return fmt.Sprintf("%s=%d", props.Label, count)
+	}, props.Label)
+	return Div.Node(HTMLProps{}, Text(label))
+})
+
+type tableProps struct {
+	WithKey
+	Name string
+	Rows int
+}
+
+var table = FunctionComponent(func(props tableProps) AnyNode {
+	rows := make([]AnyNode, props.Rows)
+	for i := range rows {
+		rows[i] = row.Node(rowProps{Label: fmt.Sprintf("%s%d", props.Name, i)})
+	}
+	return Div.Node(HTMLProps{}, rows...)
+})
+
+func expectedTable(name string, rows int) string {
+	html := "<div>"
+	for i := 0; i < rows; i++ {
+		label := fmt.Sprintf("%s%d", name, i)
+		html += fmt.Sprintf("<div>%s=%d</div>", label, len(label))
+	}
+	return html + "</div>"
+}
+
+func TestParallelRenderToString(t *testing.T) {
+	const goroutines, renders = 8, 50
+	var wg sync.WaitGroup
+	errs := make(chan string, goroutines*renders)
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < renders; i++ {
+				name := fmt.Sprintf("g%d-%d-", g, i)
+				got := RenderToString(table.Node(tableProps{Name: name, Rows: 5}))
+				if want := expectedTable(name, 5); got != want {
+					errs <- fmt.Sprintf("rendered %q, want %q", got, want)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func BenchmarkRenderToStringParallel(b *testing.B) {
+	node := table.Node(tableProps{Name: "row", Rows: 50})
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			RenderToString(node)
+		}
+	})
+}
+
+type idProps struct {
+	WithKey
+	Depth int
+}
+
+var idTree *FuncComponent[idProps]
+
+func init() {
+	idTree = FunctionComponent(func(props idProps) AnyNode {
+		first, second := UseId(), UseId()
+		ids := []AnyNode{Text("[" + first + "][" + second + "]")}
+		if props.Depth > 0 {
+			ids = append(ids,
+				idTree.Node(idProps{Depth: props.Depth - 1}),
+				Fragment(idTree.Node(idProps{WithKey: Key("x"), Depth: props.Depth - 1})),
+			)
+		}
+		return Fragment(ids...)
+	})
+}
+
+func TestUseIdMatchesServerRender(t *testing.T) {
+	node := idTree.Node(idProps{Depth: 2})
+	server := RenderToString(node, WithIdentifierPrefix("p-"))
+
+	container := testdom.NewElement("div")
+	root := NewTestDomRoot(container, WithIdentifierPrefix("p-"))
+	root.Render(node)
+	defer root.Unmount()
+	if client := innerText(container); client != server {
+		t.Errorf("client ids %s, want the server's %s", client, server)
+	}
+
+	ids := strings.Split(strings.Trim(server, "[]"), "][")
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		if !strings.HasPrefix(id, "p-") || seen[id] {
+			t.Errorf("id %q is duplicated or lacks the prefix, in %s", id, server)
+		}
+		seen[id] = true
+	}
+	if len(ids) != 14 {
+		t.Errorf("rendered %d ids, want 14", len(ids))
+	}
+}
@@ -0,0 +1,87 @@
+package reconciler
+
+import (
+	. "github.com/justjake/react4c/react"
+	"github.com/justjake/react4c/testdom"
+	. "github.com/justjake/react4c/web"
+)
+
+// RenderToTestDom renders node into parentNode, and keeps it up to date as state
+// changes.
+func RenderToTestDom(node AnyNode, parentNode testdom.Node, options ...RootOption) testdom.Node {
+	NewTestDomRoot(parentNode, options...).Render(node)
+	return parentNode
+}
+
+// NewTestDomRoot creates a Root that renders into container.
+func NewTestDomRoot(container testdom.Node, options ...RootOption) *Root {
+	var renderer Renderer
+	renderer = func(fiber *fiber, nextNode AnyNode) {
+		updateMounted := componentKindHandlers{
+			Nil: func() {},
+			Fragment: func(props FragmentProps) {
+				// TODO: how do we index this?
+				renderChildren(fiber, props.Children, renderer)
+			},
+			Text: func(props TextProps) {
+				if fiber.mounted != nil {
+					prevProps := fiber.rendered.GetProps().(TextProps)
+					if prevProps.Text != props.Text {
+						fiber.mounted.(testdom.Node).SetAttribute("innerText", props.Text)
+					}
+				} else {
+					fiber.mounted = testdom.NewText(props.Text)
+				}
+			},
+			HTML: func(tag HtmlTag, props HTMLProps) {
+				prevProps := HTMLProps{}
+				if fiber.mounted == nil {
+					fiber.mounted = testdom.NewElement(tag.TagName)
+				} else {
+					// is this supposed to be node, or rendered???
+					// seems like rendered
+					prevProps = fiber.rendered.GetProps().(HTMLProps)
+				}
+
+				element := fiber.mounted.(testdom.Node)
+				applyHtmlPropDiff(prevProps.ClassName, props.ClassName, "class", element)
+				applyHtmlPropDiff(prevProps.Style, props.Style, "style", element)
+				applyHtmlPropDiff(prevProps.Id, props.Id, "id", element)
+				applyHtmlPropDiff(prevProps.OnClick, props.OnClick, "onclick", element)
+				renderChildren(fiber, props.Children, renderer)
+			},
+			Other: func(node AnyNode) {
+				renderChildren(fiber, []AnyNode{node}, renderer)
+			},
+		}
+		updateMounted.Perform(nextNode)
+	}
+	root := newRoot(container, renderer, options...)
+	root.mutations = testdomMutations{}
+	return &Root{root}
+}
+
+type testdomMutations struct{}
+
+func (testdomMutations) InsertBefore(parent any, child any, beforeChild any) {
+	if beforeChild == nil {
+		parent.(testdom.Node).AddChildAfter(child.(testdom.Node), nil)
+	} else {
+		parent.(testdom.Node).AddChildBefore(child.(testdom.Node), beforeChild.(testdom.Node))
+	}
+}
+
+func (testdomMutations) RemoveChild(parent any, child any) {
+	parent.(testdom.Node).RemoveChild(child.(testdom.Node))
+}
+
+func applyHtmlPropDiff[T any](prev *T, next *T, name string, node testdom.Node) {
+	if ShallowEqual(prev, next) {
+		return
+	}
+	if next == nil {
+		node.DeleteAttribute(name)
+	} else {
+		node.SetAttribute(name, *next)
+	}
+}
@@ -0,0 +1,408 @@
+package reconciler
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/justjake/react4c/internal/goroutine"
+	. "github.com/justjake/react4c/react"
+)
+
+// Limit on consecutive renders caused by updates scheduled while rendering or
+// committing, eg an effect that sets state every time it runs.
+const maxNestedUpdates = 50
+
+type root struct {
+	fiber    *fiber
+	host     any
+	renderer Renderer
+	// nil for hosts that don't support mutation, like RenderToString.
+	mutations hostMutations
+	// Topmost fibers removed during this render, waiting for Sweep.
+	deletions []*fiber
+	// Fibers rendered this pass in post-order, waiting for their effects.
+	rendered []*fiber
+	// Fibers new or moved this pass in pre-order, waiting for FlushChanges.
+	placements []*fiber
+	// Fibers given new props this pass that skipped rendering.
+	bailedOut []*fiber
+	// Passive effects of unmounted fibers, waiting for cleanup.
+	passiveUnmounts []EffectHook
+	// Prepended to ids from UseId.
+	identifierPrefix string
+	// Goroutines started by components, see UseGo.
+	workers sync.WaitGroup
+	// goroutine.Current() of the running workers.
+	workerMu         sync.Mutex
+	workerGoroutines map[uintptr]bool
+	// Time source for timer hooks.
+	clock Clock
+	// Where UsePersistentState keeps values.
+	storage StateStorage
+
+	// Held while rendering and committing. Fibers may only be touched while
+	// holding renderMu.
+	renderMu  sync.Mutex
+	unmounted bool
+	// goroutine.Current() of the goroutine holding renderMu, or 0.
+	renderGoroutine uintptr
+	// If true, the goroutine holding renderMu called Render or RenderToString,
+	// so runaway updates panic to the caller instead of being dropped.
+	renderCaller bool
+	// Set when Unmount is called while rendering or committing, eg from an
+	// effect, to unmount once the commit is done.
+	unmountRequested bool
+
+	// Updates from any goroutine wait here until the next render.
+	updateMu       sync.Mutex
+	pendingFibers  []*fiber
+	pendingNested  []*fiber // Scheduled by the render or commit itself
+	pendingNode    AnyNode
+	hasPendingNode bool
+}
+
+// RootOption configures a root when it's created.
+type RootOption func(*root)
+
+// WithIdentifierPrefix prefixes ids from UseId. Give each root on the same
+// page a different prefix so their ids don't collide, and give the server and
+// client renders of a root the same prefix.
+func WithIdentifierPrefix(prefix string) RootOption {
+	return func(r *root) {
+		r.identifierPrefix = prefix
+	}
+}
+
+// WithClock makes timer hooks read time from clock, eg a FakeClock in tests.
+func WithClock(clock Clock) RootOption {
+	return func(r *root) {
+		r.clock = clock
+	}
+}
+
+// WithStateStorage makes UsePersistentState keep values in storage, eg a
+// FileStorage to keep them across restarts. By default, each root keeps them
+// in memory.
+func WithStateStorage(storage StateStorage) RootOption {
+	return func(r *root) {
+		r.storage = storage
+	}
+}
+
+func newRoot(host any, renderer Renderer, options ...RootOption) *root {
+	root := &root{
+		host:     host,
+		renderer: renderer,
+		clock:    RealClock{},
+		storage:  NewMemoryStorage(),
+	}
+	for _, option := range options {
+		option(root)
+	}
+	root.fiber = newFiber(root, nil, Fragment())
+	root.fiber.temp.placement = true
+	root.placements = []*fiber{root.fiber}
+	return root
+}
+
+// Render node as the root's only child. Blocks until node is committed, unless
+// called while this goroutine renders or commits, eg by an effect: then node is
+// committed once the render in progress is done.
+func (r *root) render(node AnyNode) {
+	r.updateMu.Lock()
+	r.pendingNode = node
+	r.hasPendingNode = true
+	r.updateMu.Unlock()
+	if atomic.LoadUintptr(&r.renderGoroutine) == goroutine.Current() {
+		return
+	}
+	r.flushUpdates(true)
+}
+
+func (r *root) scheduleUpdate(f *fiber) {
+	nested := atomic.LoadUintptr(&r.renderGoroutine) == goroutine.Current()
+	r.updateMu.Lock()
+	if nested {
+		r.pendingNested = append(r.pendingNested, f)
+	} else {
+		r.pendingFibers = append(r.pendingFibers, f)
+	}
+	r.updateMu.Unlock()
+	r.flushUpdates(false)
+}
+
+func (r *root) lockRender() {
+	r.renderMu.Lock()
+	atomic.StoreUintptr(&r.renderGoroutine, goroutine.Current())
+}
+
+func (r *root) tryLockRender() bool {
+	if !r.renderMu.TryLock() {
+		return false
+	}
+	atomic.StoreUintptr(&r.renderGoroutine, goroutine.Current())
+	return true
+}
+
+func (r *root) unlockRender() {
+	r.renderCaller = false
+	atomic.StoreUintptr(&r.renderGoroutine, 0)
+	r.renderMu.Unlock()
+}
+
+// Render pending updates on the calling goroutine. If another render is in
+// progress, including one further up this goroutine's stack, the update is
+// left for it: the rendering goroutine checks for more updates once it's done.
+//
+// renderCaller is true when called by Render, which waits for the render in
+// progress instead, so its node is committed when it returns.
+func (r *root) flushUpdates(renderCaller bool) {
+	nestedRenders := 0
+	for first := true; ; first = false {
+		if first && renderCaller {
+			r.lockRender()
+		} else if !r.tryLockRender() {
+			return
+		}
+		r.renderCaller = renderCaller
+		work, nested := r.applyPendingUpdates()
+		if !work {
+			r.unlockRender()
+			// An update queued after applyPendingUpdates, but before unlocking,
+			// failed to take renderMu and left its work for us.
+			if !r.hasPendingUpdates() {
+				return
+			}
+			continue
+		}
+
+		if len(nested) > 0 {
+			nestedRenders++
+		} else {
+			nestedRenders = 0
+		}
+		if nestedRenders > maxNestedUpdates {
+			err := fmt.Errorf("Maximum update depth exceeded: components keep updating state while rendering or committing: %s", describeFibers(nested))
+			// Drop the runaway updates, so they don't render with the next update.
+			for _, f := range nested {
+				f.dirty = false
+			}
+			panicking := r.renderCaller
+			r.unlockRender()
+			if panicking {
+				panic(err)
+			}
+			// Don't crash a goroutine that happened to flush, eg a worker.
+			Logger.Printf("root.flushUpdates(): %v", err)
+			nestedRenders = 0
+			continue
+		}
+
+		func() {
+			defer r.unlockRender()
+			r.renderAndCommit()
+			if r.unmountRequested {
+				r.unmountLocked()
+			}
+		}()
+	}
+}
+
+func (r *root) hasPendingUpdates() bool {
+	r.updateMu.Lock()
+	defer r.updateMu.Unlock()
+	return len(r.pendingFibers) > 0 || len(r.pendingNested) > 0 || r.hasPendingNode
+}
+
+// Move pending updates onto fibers. Returns false if there's nothing to do,
+// and the live fibers updated by the previous render or commit.
+func (r *root) applyPendingUpdates() (work bool, nested []*fiber) {
+	r.updateMu.Lock()
+	fibers, nestedFibers, node, hasNode := r.pendingFibers, r.pendingNested, r.pendingNode, r.hasPendingNode
+	r.pendingFibers, r.pendingNested, r.pendingNode, r.hasPendingNode = nil, nil, nil, false
+	r.updateMu.Unlock()
+
+	if r.unmounted {
+		return false, nil
+	}
+
+	if hasNode {
+		r.fiber.node = Fragment(node)
+		r.fiber.markDirty()
+		work = true
+	}
+	for _, f := range fibers {
+		if f.temp.dead {
+			continue
+		}
+		f.markDirty()
+		work = true
+	}
+	for _, f := range nestedFibers {
+		if f.temp.dead {
+			continue
+		}
+		f.markDirty()
+		nested = append(nested, f)
+		work = true
+	}
+	return work, nested
+}
+
+func describeFibers(fibers []*fiber) string {
+	chains := make([]string, len(fibers))
+	for i, f := range fibers {
+		chains[i] = f.componentChain()
+	}
+	return strings.Join(chains, ", ")
+}
+
+// Render, then commit. If an external store changed while rendering, the
+// components that read it render again first, so the commit is consistent.
+func (r *root) renderAndCommit() {
+	for attempt := 0; ; attempt++ {
+		render(r.fiber, r.renderer)
+		torn := r.tornFibers()
+		if len(torn) == 0 {
+			break
+		}
+		if attempt >= maxRenderPhaseUpdates {
+			// Commit anyway; the stores' subscriptions schedule another render.
+			Logger.Printf("root.renderAndCommit(): external stores keep changing while rendering: %s", describeFibers(torn))
+			break
+		}
+		for _, f := range torn {
+			f.markDirty()
+		}
+	}
+	r.commit()
+}
+
+// Fibers rendered this pass that read an external store that has changed since.
+func (r *root) tornFibers() []*fiber {
+	var torn []*fiber
+	for _, f := range r.rendered {
+		if f.temp.dead || f.dirty {
+			continue
+		}
+		for _, hook := range f.hooks.hooks {
+			if store, ok := hook.(ExternalStoreHook); ok && store.SnapshotChanged() {
+				torn = append(torn, f)
+				break
+			}
+		}
+	}
+	return torn
+}
+
+// Apply the render to the host, and run effects in phase order.
+func (r *root) commit() {
+	FlushInsertionEffects(r.fiber)
+	Sweep(r.fiber)
+	FlushChanges(r.fiber)
+	FlushLayoutEffects(r.fiber)
+	FlushPaint(r.fiber)
+	FlushEffects(r.fiber)
+}
+
+// Render node once, then unmount. Updates scheduled during or after the render
+// are dropped, so nothing renders into the host after renderStatic returns.
+// Effects never run, like server rendering in React.
+func (r *root) renderStatic(node AnyNode) {
+	r.lockRender()
+	defer r.unlockRender()
+	r.renderCaller = true
+	r.fiber.node = Fragment(node)
+	render(r.fiber, r.renderer)
+	r.rendered = nil
+	Sweep(r.fiber)
+	FlushChanges(r.fiber)
+	r.unmountLocked()
+}
+
+// Unmount the root's tree, running all cleanups and removing its host nodes.
+func (r *root) unmount() {
+	if atomic.LoadUintptr(&r.renderGoroutine) == goroutine.Current() {
+		// Called while this goroutine renders or commits, eg by an effect.
+		// renderMu is held further up the stack, so unmount once the commit is
+		// done.
+		r.unmountRequested = true
+		return
+	}
+	func() {
+		r.lockRender()
+		defer r.unlockRender()
+		r.unmountLocked()
+	}()
+	if r.isWorker() {
+		// The calling worker can't exit until we return.
+		return
+	}
+	// Unmounting cancelled the workers. Wait without holding renderMu, so
+	// workers that update state while exiting don't block.
+	r.workers.Wait()
+}
+
+// Run fn in a goroutine that unmount waits for.
+func (r *root) goWorker(fn func()) {
+	r.workers.Add(1)
+	go func() {
+		id := goroutine.Current()
+		r.workerMu.Lock()
+		if r.workerGoroutines == nil {
+			r.workerGoroutines = make(map[uintptr]bool)
+		}
+		r.workerGoroutines[id] = true
+		r.workerMu.Unlock()
+		defer func() {
+			r.workerMu.Lock()
+			delete(r.workerGoroutines, id)
+			r.workerMu.Unlock()
+			r.workers.Done()
+		}()
+		fn()
+	}()
+}
+
+// Reports if the calling goroutine is one of the root's workers.
+func (r *root) isWorker() bool {
+	r.workerMu.Lock()
+	defer r.workerMu.Unlock()
+	return r.workerGoroutines[goroutine.Current()]
+}
+
+func (r *root) unmountLocked() {
+	if r.unmounted {
+		return
+	}
+	r.unmounted = true
+	r.fiber.temp.dead = true
+	r.deletions = append(r.deletions, r.fiber)
+	r.rendered = nil
+	Sweep(r.fiber)
+	FlushEffects(r.fiber)
+}
+
+// Root is a tree rendered into a host container. It re-renders the parts of
+// the tree whose state changes until it's unmounted.
+type Root struct {
+	root *root
+}
+
+// Render node into the root, replacing the previous node. Components that stay
+// the same keep their state. Returns once node is committed, waiting for a render
+// in progress on another goroutine if needed.
+func (r *Root) Render(node AnyNode) {
+	r.root.render(node)
+}
+
+// Unmount the tree, running cleanups and removing its host nodes from the
+// container. Returns once goroutines started by components have exited, except
+// for the calling one when called from such a goroutine. When called while the
+// root is rendering on the same goroutine, eg from an effect or a class
+// lifecycle method, the tree is unmounted once the commit is done.
+func (r *Root) Unmount() {
+	r.root.unmount()
+}
@@ -0,0 +1,109 @@
+package reconciler
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/justjake/react4c/react"
+	"github.com/justjake/react4c/testdom"
+)
+
+func init() {
+	Logger.SetOutput(discard{})
+}
+
+type discard struct{}
+
+func (discard) Write(p []byte) (int, error) { return len(p), nil }
+
+// Text of the testdom nodes under parent, in order.
+func innerText(parent *testdom.Element) string {
+	text := ""
+	for _, child := range parent.Children {
+		switch child := child.(type) {
+		case *testdom.Text:
+			text += child.InnerText
+		case *testdom.Element:
+			text += innerText(child)
+		}
+	}
+	return text
+}
+
+func TestConcurrentStateUpdates(t *testing.T) {
+	const goroutines, updates = 8, 200
+	var increment func(func(int) int)
+	counter := FunctionComponent(func(props WithKey) AnyNode {
+		count, _, update := UseStateFn(0)
+		increment = update
+		return Text(fmt.Sprint(count))
+	})
+
+	container := testdom.NewElement("div")
+	root := NewTestDomRoot(container)
+	root.Render(counter.Node(WithKey{}))
+	update := increment
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < updates; i++ {
+				update(func(n int) int { return n + 1 })
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Every update rendered before the goroutine that queued it returned.
+	if got, want := innerText(container), fmt.Sprint(goroutines*updates); got != want {
+		t.Errorf("rendered %s, want %s", got, want)
+	}
+	root.Unmount()
+}
+
+type blockerProps struct {
+	WithKey
+	N int
+}
+
+func TestRenderWaitsForRenderInProgress(t *testing.T) {
+	entered, release := make(chan struct{}), make(chan struct{})
+	var setState func(int)
+	blocker := FunctionComponent(func(props blockerProps) AnyNode {
+		state, set := UseState(0)
+		setState = set
+		if state == 1 && props.N == 0 {
+			entered <- struct{}{}
+			<-release
+		}
+		return Text(fmt.Sprintf("%d:%d", props.N, state))
+	})
+
+	container := testdom.NewElement("div")
+	root := NewTestDomRoot(container)
+	root.Render(blocker.Node(blockerProps{}))
+	set := setState
+
+	// Render on another goroutine, and block while it's in progress.
+	go set(1)
+	<-entered
+	rendered := make(chan struct{})
+	go func() {
+		root.Render(blocker.Node(blockerProps{N: 7}))
+		close(rendered)
+	}()
+	select {
+	case <-rendered:
+		t.Fatal("Render returned before committing its node")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	<-rendered
+	if got := innerText(container); got != "7:1" {
+		t.Errorf("rendered %q, want 7:1", got)
+	}
+}
@@ -0,0 +1,58 @@
+package reconciler
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/justjake/react4c/react"
+	"github.com/justjake/react4c/testdom"
+)
+
+type equalProps struct {
+	WithKey
+	Equal func(a, b []string) bool
+}
+
+func sameStrings(a, b []string) bool {
+	return strings.Join(a, ",") == strings.Join(b, ",")
+}
+
+func TestUseStateWith(t *testing.T) {
+	renders := 0
+	var setItems func([]string)
+	list := FunctionComponent(func(props equalProps) AnyNode {
+		renders++
+		items, set := UseStateWith([]string{"a"}, props.Equal)
+		setItems = set
+		return Text(strings.Join(items, ","))
+	})
+
+	// Without equal, every set re-renders, even to an equal value.
+	container := testdom.NewElement("div")
+	root := NewTestDomRoot(container)
+	root.Render(list.Node(equalProps{}))
+	setItems([]string{"a"})
+	if renders != 2 {
+		t.Errorf("with nil equal, rendered %d times after setting an equal value, want 2", renders)
+	}
+	setItems([]string{"a", "b"})
+	if got := innerText(container); got != "a,b" {
+		t.Errorf("rendered %q, want a,b", got)
+	}
+	root.Unmount()
+
+	// With equal, setting an equal value bails out.
+	renders = 0
+	container = testdom.NewElement("div")
+	root = NewTestDomRoot(container)
+	defer root.Unmount()
+	root.Render(list.Node(equalProps{Equal: sameStrings}))
+	setItems([]string{"a"})
+	if renders != 1 {
+		t.Errorf("with equal, rendered %d times after setting an equal value, want 1", renders)
+	}
+	setItems([]string{"a", "b"})
+	if got := innerText(container); got != "a,b" || renders != 2 {
+		t.Errorf("rendered %q %d times, want a,b twice", got, renders)
+	}
+}
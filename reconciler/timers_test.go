@@ -0,0 +1,154 @@
+package reconciler
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/justjake/react4c/react"
+	"github.com/justjake/react4c/testdom"
+)
+
+type timerProps struct {
+	WithKey
+	Delay time.Duration
+	Fired chan<- time.Duration
+}
+
+var intervalComponent = FunctionComponent(func(props timerProps) AnyNode {
+	UseInterval(func() { props.Fired <- props.Delay }, props.Delay)
+	return nil
+})
+
+var timeoutComponent = FunctionComponent(func(props timerProps) AnyNode {
+	UseTimeout(func() { props.Fired <- props.Delay }, props.Delay)
+	return nil
+})
+
+func expectFired(t *testing.T, fired <-chan time.Duration, what string) {
+	t.Helper()
+	select {
+	case <-fired:
+	case <-time.After(time.Second):
+		t.Fatalf("%s never fired", what)
+	}
+}
+
+func TestUseInterval(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	fired := make(chan time.Duration, 10)
+	root := NewTestDomRoot(testdom.NewElement("div"), WithClock(clock))
+	root.Render(intervalComponent.Node(timerProps{Delay: time.Second, Fired: fired}))
+
+	for i := 0; i < 3; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(time.Second)
+		expectFired(t, fired, "interval")
+	}
+
+	// Each timer fires at most once per Advance.
+	clock.BlockUntil(1)
+	clock.Advance(3 * time.Second)
+	expectFired(t, fired, "interval")
+	clock.BlockUntil(1)
+	if len(fired) != 0 {
+		t.Errorf("interval ticked %d extra times in one Advance, want once", len(fired))
+	}
+
+	// An interval of 0 pauses it.
+	root.Render(intervalComponent.Node(timerProps{Delay: 0, Fired: fired}))
+	clock.BlockUntil(0)
+	clock.Advance(time.Minute)
+	root.Unmount()
+	if len(fired) != 0 {
+		t.Errorf("paused interval ticked %d times", len(fired))
+	}
+}
+
+func TestUseTimeout(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	fired := make(chan time.Duration, 10)
+	root := NewTestDomRoot(testdom.NewElement("div"), WithClock(clock))
+	root.Render(timeoutComponent.Node(timerProps{Delay: time.Second, Fired: fired}))
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Second - time.Millisecond)
+	if clock.Waiting() != 1 || len(fired) != 0 {
+		t.Fatal("timeout fired early")
+	}
+	clock.Advance(time.Millisecond)
+	expectFired(t, fired, "timeout")
+	clock.Advance(time.Minute)
+	root.Unmount()
+	if len(fired) != 0 {
+		t.Errorf("timeout fired %d more times, want once", len(fired))
+	}
+
+	// Unmounting cancels it.
+	root = NewTestDomRoot(testdom.NewElement("div"), WithClock(clock))
+	root.Render(timeoutComponent.Node(timerProps{Delay: time.Second, Fired: fired}))
+	clock.BlockUntil(1)
+	root.Unmount()
+	if got := clock.Waiting(); got != 0 {
+		t.Errorf("%d timers waiting after unmount, want 0", got)
+	}
+	clock.Advance(time.Minute)
+	if len(fired) != 0 {
+		t.Error("timeout fired after unmount")
+	}
+}
+
+type debounceProps struct {
+	WithKey
+	Value     string
+	Committed chan<- string
+}
+
+var debounceComponent = FunctionComponent(func(props debounceProps) AnyNode {
+	debounced := UseDebouncedValue(props.Value, time.Second)
+	UseEffect(func() func() {
+		props.Committed <- debounced
+		return nil
+	}, debounced)
+	return Text(debounced)
+})
+
+func TestUseDebouncedValue(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	committed := make(chan string, 10)
+	container := testdom.NewElement("div")
+	root := NewTestDomRoot(container, WithClock(clock))
+	root.Render(debounceComponent.Node(debounceProps{Value: "a", Committed: committed}))
+	waitForCommit(t, committed, "a")
+	// Let the first value's timer fire, so only the next one waits.
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	clock.BlockUntil(0)
+
+	root.Render(debounceComponent.Node(debounceProps{Value: "b", Committed: committed}))
+	clock.BlockUntil(1)
+	clock.Advance(time.Second - time.Millisecond)
+	if got := innerText(container); got != "a" {
+		t.Errorf("before the delay, rendered %q, want a", got)
+	}
+	clock.Advance(time.Millisecond)
+	waitForCommit(t, committed, "b")
+
+	// Values replaced before the delay never show.
+	root.Render(debounceComponent.Node(debounceProps{Value: "c", Committed: committed}))
+	root.Render(debounceComponent.Node(debounceProps{Value: "d", Committed: committed}))
+	deadline := time.Now().Add(time.Second)
+	for got := ""; got != "d"; {
+		if time.Now().After(deadline) {
+			t.Fatal("d was never committed")
+		}
+		clock.Advance(time.Second)
+		select {
+		case got = <-committed:
+			if got == "c" {
+				t.Error("committed c, which was replaced before the delay")
+			}
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	root.Unmount()
+}
@@ -0,0 +1,196 @@
+package reconciler
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/justjake/react4c/react"
+	"github.com/justjake/react4c/testdom"
+)
+
+func TestTornRenderSkipsDroppedSubtreeEffects(t *testing.T) {
+	var version int64
+	subscribe := func(func()) func() { return func() {} }
+	var effects int64
+
+	grandchild := FunctionComponent(func(props WithKey) AnyNode {
+		UseEffect(func() {
+			atomic.AddInt64(&effects, 1)
+		}, Once{})
+		UseGo(func(ctx context.Context) {
+			<-ctx.Done()
+		}, Once{})
+		return Text("grandchild")
+	})
+	child := FunctionComponent(func(props WithKey) AnyNode {
+		// Tear the parent's snapshot, so it renders again without us.
+		atomic.AddInt64(&version, 1)
+		return grandchild.Node(WithKey{})
+	})
+	parent := FunctionComponent(func(props WithKey) AnyNode {
+		v := UseSyncExternalStore(subscribe, func() int64 { return atomic.LoadInt64(&version) })
+		if v == 0 {
+			return child.Node(WithKey{})
+		}
+		return Text("done")
+	})
+
+	container := testdom.NewElement("div")
+	root := NewTestDomRoot(container)
+	root.Render(parent.Node(WithKey{}))
+	if got := innerText(container); got != "done" {
+		t.Errorf("rendered %q, want done", got)
+	}
+	if n := atomic.LoadInt64(&effects); n != 0 {
+		t.Errorf("dropped subtree ran %d effects", n)
+	}
+
+	unmounted := make(chan struct{})
+	go func() {
+		root.Unmount()
+		close(unmounted)
+	}()
+	select {
+	case <-unmounted:
+	case <-time.After(time.Second):
+		t.Fatal("Unmount hung waiting for a worker of a dropped subtree")
+	}
+}
+
+// Run fn, failing if it doesn't return within a second.
+func withinSecond(t *testing.T, what string, fn func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("%s deadlocked", what)
+	}
+}
+
+type selfUnmountProps struct {
+	WithKey
+	Root **Root
+}
+
+func TestUnmountFromEffect(t *testing.T) {
+	var cleanups int64
+	component := FunctionComponent(func(props selfUnmountProps) AnyNode {
+		UseEffect(func() func() {
+			(*props.Root).Unmount()
+			return func() { atomic.AddInt64(&cleanups, 1) }
+		}, Once{})
+		return Text("mounted")
+	})
+
+	container := testdom.NewElement("div")
+	root := NewTestDomRoot(container)
+	withinSecond(t, "Unmount from an effect", func() {
+		root.Render(component.Node(selfUnmountProps{Root: &root}))
+	})
+	if got := innerText(container); got != "" {
+		t.Errorf("rendered %q after unmount, want nothing", got)
+	}
+	if n := atomic.LoadInt64(&cleanups); n != 1 {
+		t.Errorf("ran %d cleanups, want 1", n)
+	}
+}
+
+type unmounterProps struct {
+	WithKey
+	Root **Root
+}
+
+type unmounterClass struct {
+	ClassBase[unmounterProps, int]
+}
+
+func (c *unmounterClass) Render() AnyNode {
+	return Text("mounted")
+}
+
+func (c *unmounterClass) ComponentDidMount() {
+	(*c.Props().Root).Unmount()
+}
+
+var unmounter = Class(func(unmounterProps) *unmounterClass {
+	return &unmounterClass{}
+})
+
+func TestUnmountFromLifecycle(t *testing.T) {
+	container := testdom.NewElement("div")
+	root := NewTestDomRoot(container)
+	withinSecond(t, "Unmount from ComponentDidMount", func() {
+		root.Render(unmounter.Node(unmounterProps{Root: &root}))
+	})
+	if got := innerText(container); got != "" {
+		t.Errorf("rendered %q after unmount, want nothing", got)
+	}
+}
+
+func TestUnmountFromWorker(t *testing.T) {
+	unmounted := make(chan struct{})
+	component := FunctionComponent(func(props selfUnmountProps) AnyNode {
+		UseGo(func(ctx context.Context) {
+			(*props.Root).Unmount()
+			close(unmounted)
+		}, Once{})
+		return Text("mounted")
+	})
+
+	root := NewTestDomRoot(testdom.NewElement("div"))
+	root.Render(component.Node(selfUnmountProps{Root: &root}))
+	select {
+	case <-unmounted:
+	case <-time.After(time.Second):
+		t.Fatal("Unmount from a worker deadlocked")
+	}
+	withinSecond(t, "Unmount after unmounting from a worker", root.Unmount)
+}
+
+type loggerProps struct {
+	WithKey
+	WithChildren
+	Name string
+	Log  *[]string
+}
+
+func TestUnmountCleanupOrder(t *testing.T) {
+	logger := FunctionComponent(func(props loggerProps) AnyNode {
+		UseEffect(func() func() {
+			return func() { *props.Log = append(*props.Log, props.Name+" effect") }
+		}, Once{})
+		UseLayoutEffect(func() func() {
+			return func() { *props.Log = append(*props.Log, props.Name+" layout") }
+		}, Once{})
+		return Fragment(props.Children...)
+	})
+
+	var log []string
+	tree := logger.Node(loggerProps{Name: "parent", Log: &log},
+		logger.Node(loggerProps{Name: "a", Log: &log},
+			logger.Node(loggerProps{Name: "a1", Log: &log}),
+		),
+		logger.Node(loggerProps{Name: "b", Log: &log}),
+	)
+	root := NewTestDomRoot(testdom.NewElement("div"))
+	root.Render(tree)
+	root.Unmount()
+
+	// Children clean up before their parents, and layout effects before
+	// passive effects.
+	want := []string{
+		"a1 layout", "a layout", "b layout", "parent layout",
+		"a1 effect", "a effect", "b effect", "parent effect",
+	}
+	if fmt.Sprint(log) != fmt.Sprint(want) {
+		t.Errorf("cleanups ran in order %v, want %v", log, want)
+	}
+}
@@ -107,7 +107,14 @@ func (el *Element) SetAttribute(attr string, val any) {
 	el.Attributes[attr] = val
 }
 
+// Insert node at index. If node is already a child of el, it's moved.
 func (el *Element) AddChildAt(node Node, index int) {
+	if existing := el.Index(node); existing != -1 {
+		el.Children = slices.Delete(el.Children, existing, existing+1)
+		if existing < index {
+			index--
+		}
+	}
 	node.setParent(el)
 	el.Children = slices.Insert(el.Children, index, node)
 }
@@ -145,7 +152,7 @@ func (el *Element) setParent(parent Node) {
 func (parent *Element) RemoveChild(node Node) {
 	childIndex := parent.Index(node)
 	if childIndex > -1 {
-		parent.Children = slices.Delete(parent.Children, childIndex, childIndex)
+		parent.Children = slices.Delete(parent.Children, childIndex, childIndex+1)
 	}
 }
 func (el *Text) RemoveChild(node Node) {
@@ -30,6 +30,10 @@ func (tag HtmlTag) Node(props HTMLProps, children ...AnyNode) AnyNode {
 	return JSX[HTMLProps](tag, props, children...)
 }
 
+func (tag HtmlTag) DisplayName() string {
+	return tag.TagName
+}
+
 func (tag HtmlTag) StartTag(props HTMLProps) string {
 	var builder strings.Builder
 	builder.WriteRune('<')